@@ -31,6 +31,9 @@ account=rvYAfWj5gh67oV6fW32ZzP3Aw4Eubs59B
 rPm4uZoAxbM3f6neoidWr5BMzuDB1ocRjT=sSomethingSecretGoesRightHere
 r9miHh8cFcCFaCprknpdFvyYcy95ZqKhun=sSomethingSecretGoesRightHere
 
+[ed25519]
+rGWrZyQqhTp9Xu7G5Pkayo7bXjH4k4QYpf=sEdSomethingSecretGoesRightHere
+
 */
 
 import (
@@ -163,24 +166,37 @@ func (config Config) GetAccountNickname(account data.Account) (string, bool) {
 	return nick, ok
 }
 
-// TODO support ECDSA and ed25519
 func (config Config) GetAccountKeypair(account data.Account) (util.Keypair, error) {
 	// Prefered format, secret=<secret> in a [<address>] section.
-	secret := config.Section(account.String()).Key("secret").String()
+	// Optional type=ed25519 when secret does not begin "sEd".
+	section := config.Section(account.String())
+	secret := section.Key("secret").String()
+	if secret != "" {
+		if section.HasKey("type") {
+			keyType, err := util.ParseKeyType(section.Key("type").String())
+			if err != nil {
+				return util.Keypair{}, err
+			}
+			return util.NewKeypair(secret, keyType)
+		}
+		return util.NewKeypairFromSecret(secret)
+	}
 
+	// historical format, <address>=<secret>
+	secret = config.Section("ed25519").Key(account.String()).String()
+	if secret != "" {
+		return util.NewKeypair(secret, data.Ed25519)
+	}
+	secret = config.Section("ecdsa").Key(account.String()).String()
 	if secret == "" {
-		// historical format, <address>=<secret>
-		secret := config.Section("ecdsa").Key(account.String()).String()
-		if secret == "" {
-			// Top-level section is assumed ECDSA
-			secret = config.Section("").Key(account.String()).String()
-		}
+		// Top-level section is assumed ECDSA
+		secret = config.Section("").Key(account.String()).String()
 	}
 
 	if secret == "" {
 		return util.Keypair{}, fmt.Errorf("No secret found for %s", account)
 	}
-	return util.NewEcdsaFromSecret(secret)
+	return util.NewKeypairFromSecret(secret)
 
 }
 
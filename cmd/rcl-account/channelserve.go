@@ -0,0 +1,123 @@
+// Copyright (C) 2020  David N. Cohen
+// This file is part of github.com/dncohen/rcl
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Command RCL-account - Operation Channel-serve
+//
+//    rcl-account channel-serve [-listen=<address>] [-dir=<directory>] [-key=<file>]
+//
+// Serves off-ledger payment channel claims over HTTP, until
+// interrupted.  As a payee, it verifies claims posted to `/receive`
+// and keeps the best claim of each channel.  With -key (an
+// `.rcl-key` file, whose public key is the channel's), it is also a
+// payer, signing claims requested via `/pay`.  Each claim authorizes
+// a cumulative amount, greater than the last, and no more than the
+// channel holds on-ledger.
+//
+//    curl -d '{"channel": "<channel ID>", "amount": "1000"}' localhost:8080/pay
+//    curl localhost:8080/receive?channel=<channel ID>
+//
+// Claims are saved under -dir, so are not lost when restarted.  There
+// is no authentication, so listen only on a local or protected
+// address.  See `rcl-tx channel claim` to redeem a claim on-ledger.
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sync"
+
+	"src.d10.dev/command"
+
+	"github.com/dncohen/rcl/internal/cmd"
+	"github.com/dncohen/rcl/paychan"
+	"github.com/dncohen/rcl/rpc"
+	"github.com/rubblelabs/ripple/data"
+)
+
+func init() {
+	command.RegisterOperation(command.Operation{
+		Handler:     opChannelServe,
+		Name:        "channel-serve",
+		Syntax:      "channel-serve [-listen=<address>] [-dir=<directory>] [-key=<file>]",
+		Description: `Sign and receive payment channel claims over HTTP.`,
+	})
+}
+
+func opChannelServe() error {
+	listenFlag := command.OperationFlagSet.String("listen", "localhost:8080", "HTTP address to listen on")
+	dirFlag := command.OperationFlagSet.String("dir", "channels", "directory where claims are saved")
+	keyFlag := command.OperationFlagSet.String("key", "", "key file which signs claims (if omitted, only receive claims)")
+
+	err := command.ParseOperationFlagSet()
+	command.CheckUsage(err)
+
+	rippled, err := cmd.Rippled()
+	command.Check(err)
+
+	ledger := &reconnectingLedger{url: rippled}
+
+	var payer *paychan.Payer
+	if *keyFlag != "" {
+		k, err := cmd.LoadKey(*keyFlag)
+		command.Check(err)
+		kp, err := cmd.Keypair(k)
+		command.Check(err)
+
+		store, err := paychan.NewStore(filepath.Join(*dirFlag, "signed"))
+		command.Check(err)
+		payer = paychan.NewPayer(kp, ledger, store)
+		command.Infof("signing claims with key of %s", cmd.FormatAccount(k.Account, nil))
+	}
+
+	store, err := paychan.NewStore(filepath.Join(*dirFlag, "received"))
+	command.Check(err)
+	payee := paychan.NewPayee(ledger, store)
+
+	command.Infof("listening on %s", *listenFlag)
+	return http.ListenAndServe(*listenFlag, paychan.Handler(payer, payee))
+}
+
+// reconnectingLedger looks up channels via rippled, reconnecting
+// when a long-running server's connection has failed.
+type reconnectingLedger struct {
+	url string
+	ws  *rpc.Websocket
+	mu  sync.Mutex
+}
+
+func (l *reconnectingLedger) PaymentChannel(id data.Hash256) (*rpc.PaymentChannel, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for attempt := 0; ; attempt++ {
+		if l.ws == nil {
+			ws, err := rpc.NewWebsocket(l.url)
+			if err != nil {
+				return nil, fmt.Errorf("failed to connect to %s: %w", l.url, err)
+			}
+			l.ws = ws
+		}
+		channel, err := l.ws.PaymentChannel(id)
+		if _, ok := err.(*rpc.WebsocketError); err == nil || ok || attempt > 0 {
+			// success, or an error from rippled (not the connection)
+			return channel, err
+		}
+		command.V(1).Infof("reconnecting to %s after error: %s", l.url, err)
+		l.ws.Close()
+		l.ws = nil
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright (C) 2020  David N. Cohen
+// This file is part of github.com/dncohen/rcl
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Command RCL-account - Operation Checks
+//
+//    rcl-account checks <address> [<address> ...]
+//
+// Lists outstanding checks, both outgoing (sent by the account) and
+// incoming (which the account may cash).  The ID shown is used by
+// `rcl-tx check cash` and `rcl-tx check cancel`.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"golang.org/x/sync/errgroup"
+	"src.d10.dev/command"
+
+	"github.com/dncohen/rcl/internal/cmd"
+	"github.com/dncohen/rcl/rpc"
+)
+
+func init() {
+	command.RegisterOperation(command.Operation{
+		Handler:     opChecks,
+		Name:        "checks",
+		Syntax:      "checks <account> [...]",
+		Description: `Show outstanding checks, incoming and outgoing.`,
+	})
+}
+
+func opChecks() error {
+	err := command.ParseOperationFlagSet()
+	command.CheckUsage(err)
+
+	account, err := cmd.ParseAccountArg(command.OperationFlagSet.Args())
+	command.Check(err)
+	if len(account) == 0 {
+		command.CheckUsage(errors.New("expected one or more addresses"))
+	}
+
+	rippled, err := cmd.Rippled()
+	command.Check(err)
+
+	ws, err := rpc.NewWebsocket(rippled)
+	if err != nil {
+		command.Check(fmt.Errorf("Failed to connect to %s: %s", rippled, err))
+	}
+	defer ws.Close()
+
+	checks := make([][]rpc.Check, len(account))
+	g := new(errgroup.Group)
+	for i, acct := range account {
+		i, acct := i, acct
+		g.Go(func() error {
+			var err error
+			checks[i], err = ws.Checks(acct.Account)
+			if err != nil {
+				return fmt.Errorf("account_objects failed for %s: %w", acct.Account, err)
+			}
+			return nil
+		})
+	}
+	err = g.Wait()
+	command.Check(err)
+
+	for i, acct := range account {
+		if len(checks[i]) == 0 {
+			fmt.Printf("%s has no outstanding checks.\n\n", cmd.FormatAccount(acct.Account, nil))
+			continue
+		}
+		table := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', tabwriter.Debug)
+		fmt.Fprintln(table, "Account\t In/Out\t Counterparty\t SendMax\t Expires\t Invoice\t ID\t")
+		for _, check := range checks[i] {
+			direction, counterparty, tag := "out", check.Destination, check.DestinationTag
+			if check.Destination == acct.Account {
+				direction, counterparty, tag = "in", check.Account, check.SourceTag
+			}
+			expires := ""
+			if check.Expiration != nil {
+				expires = cmd.FormatRippleTime(*check.Expiration)
+			}
+			invoice := ""
+			if check.InvoiceID != nil {
+				invoice = check.InvoiceID.String()
+			}
+			fmt.Fprintf(table, "%s\t %s\t %s\t %s\t %s\t %s\t %s\t\n",
+				cmd.FormatAccount(acct.Account, nil),
+				direction,
+				cmd.FormatAccount(counterparty, tag),
+				check.SendMax,
+				expires,
+				invoice,
+				check.Index,
+			)
+		}
+		table.Flush()
+		fmt.Println("") // blank line
+	}
+
+	return nil
+}
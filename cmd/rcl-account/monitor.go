@@ -140,6 +140,7 @@ func opMonitor() error {
 			_ = txs
 			g := new(errgroup.Group)
 			for _, acct := range account {
+				acct := acct // https://golang.org/doc/faq#closures_and_goroutines
 				g.Go(func() error {
 					//log.Printf("requesting %d", idx) // debug
 					txChan := subscription.Remote.AccountTx(acct.Account, 10, int64(idx), int64(idx))
@@ -18,7 +18,8 @@
 //
 //    rcl-account show <address> [<address> ...]
 //
-// Prints in human-readable format the balances of one or more accounts.
+// Prints in human-readable format the balances of one or more
+// accounts.  Also shows each account's signer list, if it has one.
 package main
 
 import (
@@ -34,6 +35,7 @@ import (
 	"src.d10.dev/command"
 
 	"github.com/dncohen/rcl/internal/cmd"
+	"github.com/dncohen/rcl/rpc"
 	"github.com/rubblelabs/ripple/data"
 	"github.com/rubblelabs/ripple/websockets"
 )
@@ -84,11 +86,19 @@ func opShow() error {
 		command.Check(fmt.Errorf("Failed to connect to %s: %s", rippled, err))
 	}
 
+	// signer lists are not supported by rubblelabs remote
+	ws, err := rpc.NewWebsocket(rippled)
+	if err != nil {
+		command.Check(fmt.Errorf("Failed to connect to %s: %s", rippled, err))
+	}
+	defer ws.Close()
+
 	// prepare to store data
 	mutex := &sync.Mutex{}
 	linesResults := make(map[data.Account]*websockets.AccountLinesResult)
 	accountResults := make(map[data.Account]*websockets.AccountInfoResult)
 	offerResults := make(map[data.Account]*websockets.AccountOffersResult)
+	signerResults := make(map[data.Account]*rpc.SignerList)
 
 	g := new(errgroup.Group)
 
@@ -98,7 +108,7 @@ func opShow() error {
 			// TODO handle results with marker!
 			result, err := remote.AccountLines(acct.Account, ledger)
 			if err != nil {
-				command.Errorf("account_lines failed for %s (at ledger %s): %s", acct, ledger, err)
+				command.Errorf("account_lines failed for %s (at ledger %s): %s", acct.Account, ledger, err)
 				return err
 			} else {
 				mutex.Lock()
@@ -112,7 +122,7 @@ func opShow() error {
 		g.Go(func() error {
 			result, err := remote.AccountInfo(acct.Account)
 			if err != nil {
-				command.Errorf("account_info failed for %s: %s", acct, err)
+				command.Errorf("account_info failed for %s: %s", acct.Account, err)
 				return err
 			} else {
 				mutex.Lock()
@@ -126,7 +136,7 @@ func opShow() error {
 		g.Go(func() error {
 			result, err := remote.AccountOffers(acct.Account, ledger)
 			if err != nil {
-				command.Errorf("account_offers failed for %s: %s", acct, err)
+				command.Errorf("account_offers failed for %s: %s", acct.Account, err)
 				return err
 			} else {
 				mutex.Lock()
@@ -136,6 +146,18 @@ func opShow() error {
 				return nil
 			}
 		})
+
+		g.Go(func() error {
+			result, err := ws.SignerList(acct.Account)
+			if err != nil {
+				return fmt.Errorf("account_objects failed for %s: %w", acct.Account, err)
+			}
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			signerResults[acct.Account] = result
+			return nil
+		})
 	}
 	// Wait for all requests to complete
 	err = g.Wait()
@@ -161,6 +183,16 @@ func opShow() error {
 		table.Flush()
 		fmt.Println("") // blank line
 
+		if signers := signerResults[key]; signers != nil {
+			table = tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', tabwriter.Debug)
+			fmt.Fprintf(table, "Signers (quorum %d)\t Weight\t\n", signers.SignerQuorum)
+			for _, entry := range signers.SignerEntries {
+				fmt.Fprintf(table, "%s\t %d\t\n", cmd.FormatAccount(entry.SignerEntry.Account, nil), entry.SignerEntry.SignerWeight)
+			}
+			table.Flush()
+			fmt.Println("") // blank line
+		}
+
 		table = tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', tabwriter.DiscardEmptyColumns|tabwriter.Debug)
 		fmt.Fprintln(table, "Balances\t Amount\t Currency/Issuer\t Min\t Max\t rippling\t quality\t")
 		fmt.Fprintf(table, "%s\t %s\t %s\t\t\t\t\t\n", cmd.FormatAccount(*account, nil), accountResult.AccountData.Balance, "XRP")
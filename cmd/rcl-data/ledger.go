@@ -457,7 +457,7 @@ func (this *LedgerTransaction) NormalizePrice(dataClient rippledata.Client, base
 				// make slow request for historic price
 				ledgerPriceCache[amount.Currency], err = dataClient.Normalize(*amount, base, s.event.GetExecutedTime())
 				if err != nil {
-					command.Error("failed to normalize price of %s on %s", amount, s.event.GetExecutedTime().Format("2006/01/02 15:04:05"))
+					command.Errorf("failed to normalize price of %s on %s", amount, s.event.GetExecutedTime().Format("2006/01/02 15:04:05"))
 					fmt.Printf("; FIXME: failed to normalize price of %s on %s\n", amount, s.event.GetExecutedTime().Format("2006/01/02 15:04:05"))
 					delete(ledgerPriceCache, amount.Currency) // just in case Normalize returned non-nil
 					continue
@@ -0,0 +1,163 @@
+// Copyright (C) 2020  David N. Cohen
+// This file is part of github.com/dncohen/rcl
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Command rcl-key - Operation audit
+//
+// Each time sign is asked to sign a transaction, it appends an entry
+// to an audit log, `rcl-key.audit`, next to the key files.  The entry
+// notes the time, signer, transaction type, hash, amount (and
+// SendMax, of a payment) and destination, and whether the transaction
+// was signed.  When it was
+// not, the entry notes why, for example forbidden by policy (see
+// sign -policy).  Sign fails, writing no signed transaction, if it
+// cannot append to the log.
+//
+// Each entry is chained to the one before it, by hash.  The verify
+// subcommand detects entries edited, removed or inserted:
+//
+//   rcl-key audit verify [<log file>]
+//
+// Verify shows the number of entries and the hash of the last.  Note
+// that hash somewhere safe, to later detect entries removed from the
+// end of the log.
+//
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/dncohen/rcl/internal/audit"
+	"github.com/dncohen/rcl/internal/policy"
+	"github.com/rubblelabs/ripple/data"
+	"src.d10.dev/command"
+)
+
+// default audit log, next to key files
+const auditFilename = "rcl-key.audit"
+
+func init() {
+	command.RegisterOperation(command.Operation{
+		Handler:     opAudit,
+		Name:        "audit",
+		Syntax:      "audit verify [<filename>]",
+		Description: "Verify the log of transactions signed.",
+	})
+}
+
+func opAudit() error {
+	err := command.ParseOperationFlagSet()
+	if err != nil {
+		return err
+	}
+
+	argument := command.OperationFlagSet.Args()
+	if len(argument) < 1 {
+		command.CheckUsage(errors.New("expected subcommand (verify)"))
+	}
+	subcommand := argument[0]
+	argument = argument[1:]
+
+	switch subcommand {
+	case "verify":
+		filename := auditFilename
+		switch len(argument) {
+		case 0:
+		case 1:
+			filename = argument[0]
+		default:
+			command.CheckUsage(errors.New("expected at most one log file"))
+		}
+
+		f, err := os.Open(filename)
+		command.Check(err)
+		defer f.Close()
+
+		count, last, err := audit.Verify(f)
+		if err != nil {
+			command.Check(fmt.Errorf("audit log %q failed verification, %w", filename, err))
+		}
+		if last == nil {
+			fmt.Printf("%s: empty\n", filename)
+			return nil
+		}
+		fmt.Printf("%s: %d entries verified, last %d at %s, hash %s\n", filename, count, last.Sequence, last.Time.Format(time.RFC3339), last.Hash)
+
+	default:
+		command.CheckUsage(fmt.Errorf("unexpected subcommand (%q)", subcommand))
+	}
+	return nil
+}
+
+// audit logs, by directory
+var auditCache = make(map[string]*audit.Log)
+
+// auditLog opens the log next to a signer's key file.
+func auditLog(signer data.Account) (*audit.Log, error) {
+	keyfile, err := keyFilename(signer)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(keyfile)
+	log, ok := auditCache[dir]
+	if !ok {
+		log, err = audit.Open(filepath.Join(dir, auditFilename))
+		if err != nil {
+			return nil, err
+		}
+		auditCache[dir] = log
+	}
+	return log, nil
+}
+
+// auditSign logs a decision to sign a transaction, or not.  Signed is
+// nil when not signed.
+func auditSign(unsigned, signed data.Transaction, decision, policyDecision string) error {
+	signer := signerOf(unsigned)
+	log, err := auditLog(signer)
+	if err != nil {
+		return err
+	}
+
+	entry := audit.Entry{
+		Time:     time.Now().UTC(),
+		Signer:   signer.String(),
+		Account:  unsigned.GetBase().Account.String(),
+		Type:     unsigned.GetType(),
+		Decision: decision,
+		Policy:   policyDecision,
+	}
+	if signed != nil && !signed.GetHash().IsZero() {
+		entry.TxHash = signed.GetHash().String()
+	}
+	// a payment's Amount is what it delivers; SendMax, if any, the
+	// most it may spend
+	if payment, ok := unsigned.(*data.Payment); ok {
+		entry.Amount = payment.Amount.String()
+		if payment.SendMax != nil {
+			entry.SendMax = payment.SendMax.String()
+		}
+	} else if amount := policy.Spend(unsigned); amount != nil {
+		entry.Amount = amount.String()
+	}
+	if destination := policy.Destination(unsigned); destination != nil {
+		entry.Destination = destination.String()
+	}
+	return log.Append(entry)
+}
@@ -20,11 +20,12 @@
 // you to copy the secret to a paper backup.
 //
 // The backup operation also produces a `.cfg` file that corresponds
-// to an `.rcl-key` file. Note each `.rcl-key` files contains an
-// unencrypted secret, and should be handled securely.  The
+// to an `.rcl-key` file. Note each `.rcl-key` files contains a secret
+// (encrypted, unless generated with -plaintext), and should be
+// handled securely.  The
 // corresponding `.cfg` created by this operation contains a public
-// address and optional nickname; it does not include a secret key, so
-// may be shared and stored less securely.
+// address, public key and optional nickname; it does not include a
+// secret key, so may be shared and stored less securely.
 //
 // The `.rcl-key` file must be available to the `rcl-key` command when
 // signing transactions.  While the `.cfg` file should be available to
@@ -37,10 +38,9 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
-	"path/filepath"
 	"runtime"
 
-	"github.com/pkg/errors"
+	"github.com/dncohen/rcl/internal/cmd"
 	"src.d10.dev/command"
 )
 
@@ -59,26 +59,15 @@ func opBackup() error {
 		return err
 	}
 
-	argument := command.OperationFlagSet.Args()
-	if len(argument) == 0 {
-		return errors.New("operation expects a list of key files")
+	arg, err := keyFileArgs(command.OperationFlagSet.Args())
+	if err != nil {
+		return err
 	}
 
 	scanner := bufio.NewScanner(os.Stdin)
 
-	// expand globs for inferior operating systems
-	arg := make([]string, 0, len(argument))
-	for _, a := range argument {
-		match, err := filepath.Glob(a)
-		command.Check(err)
-		for _, m := range match {
-			arg = append(arg, m)
-		}
-	}
-
 	for _, filename := range arg {
-		k := &Key{}
-		err := ReadKeyFromFile(k, filename)
+		k, err := cmd.LoadKey(filename)
 		command.Check(err)
 
 		txt := ""
@@ -117,6 +106,10 @@ func opBackup() error {
 		}
 
 		// save a config file
+		kp, err := cmd.Keypair(k)
+		command.Check(err)
+		pubkey := kp.PublicKey()
+
 		nick := k.Nickname
 		if nick == "" {
 			nick = k.Account.String()
@@ -127,6 +120,7 @@ func opBackup() error {
 		command.Check(err)
 		fmt.Fprintf(file, "[%s]\n", nick)
 		fmt.Fprintf(file, "\taddress=%s\n", k.Account)
+		fmt.Fprintf(file, "\tpubkey=%X\n", pubkey[:])
 		file.Close()
 		command.Infof("wrote public address %s to file %q\n", k.Account, cfgname)
 	}
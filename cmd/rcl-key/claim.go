@@ -0,0 +1,95 @@
+// Copyright (C) 2020  David N. Cohen
+// This file is part of github.com/dncohen/rcl
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Command rcl-key - Operation claim
+//
+// Claim signs an off-ledger claim against a payment channel, which
+// the channel's destination may redeem (see `rcl-tx channel claim`).
+// The claim is written to stdout, in JSON format.
+//
+//   rcl-key -as treasury claim <channel ID> 10/XRP > claim.json
+//
+// The -as account names the key which signs, that is, the key
+// specified as the channel's public key when it was opened.  Its
+// `.rcl-key` file must be present.
+//
+// The amount is cumulative.  Each claim replaces earlier claims on the
+// same channel, so to pay 1 XRP more, sign a claim 1 XRP greater than
+// the last.
+//
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/dncohen/rcl/internal/cmd"
+	"github.com/rubblelabs/ripple/data"
+	"src.d10.dev/command"
+)
+
+func init() {
+	command.RegisterOperation(command.Operation{
+		Handler:     opClaim,
+		Name:        "claim",
+		Syntax:      "claim <channel> <amount>",
+		Description: "Sign a payment channel claim.",
+	})
+}
+
+func opClaim() error {
+	err := command.ParseOperationFlagSet()
+	if err != nil {
+		return err
+	}
+
+	argument := command.OperationFlagSet.Args()
+	if len(argument) != 2 {
+		return errors.New("expected <channel> and <amount> arguments")
+	}
+	if asAccount == nil {
+		return errors.New("claim requires channel key address (-as=<address>)")
+	}
+
+	channel, err := data.NewHash256(argument[0])
+	if err != nil {
+		return fmt.Errorf("bad channel ID (%q): %w", argument[0], err)
+	}
+	amount, err := cmd.AmountFromArg(argument[1])
+	if err != nil {
+		return fmt.Errorf("bad amount (%q): %w", argument[1], err)
+	}
+
+	// the channel key, not the account's regular key
+	filename := fmt.Sprintf("%s.rcl-key", asAccount)
+	k, err := cmd.LoadKey(filename)
+	command.Check(err)
+	kp, err := cmd.Keypair(k)
+	command.Check(err)
+
+	claim, err := kp.SignClaim(*channel, *amount)
+	command.Check(err)
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "\t")
+	err = enc.Encode(claim)
+	command.Check(err)
+
+	command.V(1).Infof("signed claim of %s on channel %s", amount, channel)
+	return nil
+}
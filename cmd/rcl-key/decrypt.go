@@ -0,0 +1,69 @@
+// Copyright (C) 2020  David N. Cohen
+// This file is part of github.com/dncohen/rcl
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Command rcl-key - Operation decrypt
+//
+// Decrypt replaces encrypted `.rcl-key` files with plain text
+// versions.  Use this, for example, to change the passphrase of a key
+// file (decrypt, then encrypt again).  The plain text secret should
+// not be left on disk longer than necessary.
+//
+package main
+
+import (
+	"github.com/dncohen/rcl/internal/cmd"
+	"github.com/dncohen/rcl/internal/keyfile"
+	"src.d10.dev/command"
+)
+
+func init() {
+	command.RegisterOperation(command.Operation{
+		Handler:     opDecrypt,
+		Name:        "decrypt",
+		Syntax:      "decrypt <filename> [...]",
+		Description: "Remove passphrase protection from key files.",
+	})
+}
+
+func opDecrypt() error {
+	err := command.ParseOperationFlagSet()
+	if err != nil {
+		return err
+	}
+
+	filename, err := keyFileArgs(command.OperationFlagSet.Args())
+	if err != nil {
+		return err
+	}
+
+	for _, fname := range filename {
+		k, err := keyfile.Read(fname)
+		command.Check(err)
+		if !k.IsEncrypted() {
+			command.Infof("%s is not encrypted (unchanged)", fname)
+			continue
+		}
+
+		k, err = cmd.LoadKey(fname) // prompts for passphrase
+		command.Check(err)
+
+		err = keyfile.Replace(k, fname)
+		command.Check(err)
+		command.Infof("decrypted %s, secret is now stored in plain text", fname)
+	}
+
+	return nil
+}
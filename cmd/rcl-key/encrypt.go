@@ -0,0 +1,100 @@
+// Copyright (C) 2020  David N. Cohen
+// This file is part of github.com/dncohen/rcl
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Command rcl-key - Operation encrypt
+//
+// Encrypt the secret in existing `.rcl-key` files, for instance files
+// generated before encryption was the default.  The operation prompts
+// for a new passphrase, then replaces each file with an encrypted
+// version.  Files already encrypted are left unchanged.
+//
+//   rcl-key encrypt *.rcl-key
+//
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/dncohen/rcl/internal/cmd"
+	"github.com/dncohen/rcl/internal/keyfile"
+	"src.d10.dev/command"
+)
+
+func init() {
+	command.RegisterOperation(command.Operation{
+		Handler:     opEncrypt,
+		Name:        "encrypt",
+		Syntax:      "encrypt <filename> [...]",
+		Description: "Protect the secret in key files with a passphrase.",
+	})
+}
+
+func opEncrypt() error {
+	err := command.ParseOperationFlagSet()
+	if err != nil {
+		return err
+	}
+
+	filename, err := keyFileArgs(command.OperationFlagSet.Args())
+	if err != nil {
+		return err
+	}
+
+	var passphrase []byte
+	for _, fname := range filename {
+		k, err := keyfile.Read(fname)
+		command.Check(err)
+
+		if k.IsEncrypted() {
+			command.Infof("%s is already encrypted (unchanged)", fname)
+			continue
+		}
+
+		if passphrase == nil { // prompt only when needed
+			passphrase, err = cmd.ReadNewPassword("Passphrase to encrypt key file(s): ")
+			command.Check(err)
+		}
+
+		err = k.Encrypt(passphrase)
+		command.Check(err)
+		err = keyfile.Replace(k, fname)
+		command.Check(err)
+		command.Infof("encrypted %s", fname)
+	}
+
+	return nil
+}
+
+// expand globs for inferior operating systems
+func keyFileArgs(argument []string) ([]string, error) {
+	if len(argument) == 0 {
+		return nil, errors.New("operation expects a list of key files")
+	}
+	arg := make([]string, 0, len(argument))
+	for _, a := range argument {
+		match, err := filepath.Glob(a)
+		if err != nil {
+			return arg, err
+		}
+		if len(match) == 0 {
+			return arg, fmt.Errorf("key file not found (%q)", a)
+		}
+		arg = append(arg, match...)
+	}
+	return arg, nil
+}
@@ -18,9 +18,19 @@
 //
 // Generate new keypairs and addresses for use on the Ripple Consensus Ledger.
 //
+// Keys are secp256k1 by default.  Use `-type=ed25519` for ed25519
+// keys, whose secrets begin "sEd".  Vanity mode (-vanity) works with
+// either type.  With -secret, the type is detected from the secret,
+// unless specified with -type (needed for an ed25519 secret not
+// beginning "sEd").
+//
 // Generated keys are saved to a file named '[ADDRESS].rcl-key', where
-// [ADDRESS] is the address derived from the public key. The file is
-// not encrypted, so handle with care.
+// [ADDRESS] is the address derived from the public key. The secret in
+// the file is encrypted with a passphrase, which generate prompts for.
+// Other operations, i.e. sign and backup, prompt for the passphrase
+// when they read the file.  Use -plaintext to save an unencrypted
+// secret (not recommended), and the encrypt operation to protect such
+// a file later.
 //
 // After generating `.rcl-key` files, run the `backup` operation.
 // Doing so generates a `.cfg` file containing the public address and
@@ -40,6 +50,9 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/dncohen/rcl/internal/cmd"
+	"github.com/dncohen/rcl/internal/keyfile"
+	"github.com/dncohen/rcl/util"
 	"github.com/pkg/errors"
 	"github.com/rubblelabs/ripple/data"
 	"golang.org/x/crypto/ssh/terminal"
@@ -54,25 +67,40 @@ type key struct {
 	nickname string
 }
 
-func newKey(secret string) (*key, error) {
-	seed, err := data.NewSeedFromAddress(secret) // assuming the "Address" is a typo in "NewSeedFromAddress
+// newKey parses an existing secret.  If keyType is nil, it is
+// detected from the secret.
+func newKey(secret string, keyType *data.KeyType) (*key, error) {
+	seed, typ, err := util.DecodeSeed(secret)
 	if err != nil {
 		return nil, err
 	}
 
-	// TODO(dnc): support all key types
-	typ := data.ECDSA
-	seq := uint32(0)
-	acc := seed.AccountId(typ, &seq)
+	if keyType != nil {
+		if typ == data.Ed25519 && *keyType != data.Ed25519 {
+			return nil, fmt.Errorf("expected %s secret, got %s", util.FormatKeyType(*keyType), util.FormatKeyType(typ))
+		}
+		typ = *keyType
+	}
+	seq := keySequence(typ)
+	acc := seed.AccountId(typ, seq)
 
 	return &key{
-		seed:    *seed,
+		seed:    seed,
 		keyType: typ,
-		seq:     &seq,
+		seq:     seq,
 		account: acc,
 	}, nil
 }
 
+// secp256k1 keys use the first (0) of the family generator; ed25519 keys have no sequence
+func keySequence(keyType data.KeyType) *uint32 {
+	if keyType == data.Ed25519 {
+		return nil
+	}
+	seq := uint32(0)
+	return &seq
+}
+
 func generate(keyType data.KeyType, seq *uint32) (*key, error) {
 
 	key := &key{
@@ -94,19 +122,16 @@ func generate(keyType data.KeyType, seq *uint32) (*key, error) {
 	copy(key.seed[:], seedBytes)
 	// Now seed has 16 bytes from crypt.Rand.
 
-	secret, err := key.seed.Hash()
-	if err != nil {
-		return key, err
-	}
+	secret := util.EncodeSeed(key.seed, key.keyType)
 
 	key.account = key.seed.AccountId(key.keyType, key.seq)
 
 	// Sanity check
-	sanity, err := data.NewSeedFromAddress(secret.String())
+	sanity, sanityType, err := util.DecodeSeed(secret)
 	if err != nil {
 		return key, err
 	}
-	if *sanity != key.seed {
+	if sanity != key.seed || sanityType != key.keyType {
 		return key, fmt.Errorf("Seed sanity check failure.")
 	}
 
@@ -117,7 +142,7 @@ func init() {
 	command.RegisterOperation(command.Operation{
 		Handler:     opGenerate,
 		Name:        "generate",
-		Syntax:      "generate [-n=<int>] [-vanity=<regex>] [-nickname=<nick>]",
+		Syntax:      "generate [-n=<int>] [-type=ed25519|secp256k1] [-vanity=<regex>] [-nickname=<nick>] [-plaintext]",
 		Description: `Operation "generate" creates a new RCL address with signing key.`,
 	})
 }
@@ -128,20 +153,30 @@ func opGenerate() error {
 	vanityFlag := command.OperationFlagSet.String("vanity", "", "optional regular expression to match")
 	nicknameFlag := command.OperationFlagSet.String("nickname", "", "give generated address a nickname")
 	secretFlag := command.OperationFlagSet.Bool("secret", false, "prompt for existing secret, instead of generating a new one")
-
-	// TODO(dnc): choose any supported curve
+	plaintextFlag := command.OperationFlagSet.Bool("plaintext", false, "save secret without encryption (not recommended)")
+	typeFlag := command.OperationFlagSet.String("type", "", fmt.Sprintf("key type, %q or %q (default %s, or detected from -secret)", util.KeyTypeEd25519, util.KeyTypeSecp256k1, util.KeyTypeSecp256k1))
 
 	err := command.ParseOperationFlagSet()
 	if err != nil {
 		return err
 	}
 
+	keyType := data.ECDSA
+	var secretType *data.KeyType // nil, to detect type of existing secret
+	if *typeFlag != "" {
+		keyType, err = util.ParseKeyType(*typeFlag)
+		if err != nil {
+			return err
+		}
+		secretType = &keyType
+	}
+
 	if *nFlag <= 0 {
 		return fmt.Errorf("count parameter (%d) must be positive number", *nFlag)
 	}
 
-	matched := make(chan *Key, 0) // addresses that match vanity expression
-	unmatched := matched          // same channel if vanityFlag empty
+	matched := make(chan *keyfile.Key, 0) // addresses that match vanity expression
+	unmatched := matched                  // same channel if vanityFlag empty
 
 	discards := 0
 	pairs := 0
@@ -154,7 +189,7 @@ func opGenerate() error {
 		command.V(1).Infof("Attempting to generate %d address matching %q.", *nFlag, *vanityFlag)
 
 		// prepare to filter matches
-		unmatched = make(chan *Key, 1)
+		unmatched = make(chan *keyfile.Key, 1)
 		go func() {
 			for k := range unmatched {
 				if exp.MatchString(k.Account.String()) {
@@ -184,7 +219,7 @@ func opGenerate() error {
 			}
 			break
 		}
-		key, err := newKey(string(b))
+		key, err := newKey(string(b), secretType)
 		if err != nil {
 			// err may leak secret key, so we do not show it here
 			// fmt.Println(err)
@@ -193,13 +228,19 @@ func opGenerate() error {
 		}
 
 		keyIn = append(keyIn, key)
-		fmt.Printf("secret for address %q entered\n", key.account)
+		fmt.Printf("%s secret for address %q entered\n", util.FormatKeyType(key.keyType), key.account)
 
 		if len(keyIn) >= *nFlag {
 			break
 		}
 	}
 
+	var passphrase []byte
+	if !*plaintextFlag {
+		passphrase, err = cmd.ReadNewPassword("Passphrase to encrypt key file(s): ")
+		command.Check(err)
+	}
+
 	workers := *nFlag
 	if len(keyIn) > 0 {
 		workers = 1 // so only one worker will be started when keys are passed in
@@ -221,18 +262,15 @@ func opGenerate() error {
 					}
 					key = keyIn[pairs]
 				} else {
-					seq := uint32(0)
-					key, err = generate(data.ECDSA, &seq)
+					key, err = generate(keyType, keySequence(keyType))
 				}
 				command.Check(err)
 
-				hash, err := key.seed.Hash()
-				if err != nil {
-					command.Error(err) // reached?
-					continue
+				unmatched <- &keyfile.Key{
+					Account: key.account,
+					Secret:  util.EncodeSeed(key.seed, key.keyType),
+					Type:    util.FormatKeyType(key.keyType),
 				}
-
-				unmatched <- &Key{Account: key.account, Secret: hash.String()}
 				pairs++
 			}
 			log.Println("worker exiting") // debug
@@ -257,9 +295,14 @@ func opGenerate() error {
 				}
 			}
 
+			if passphrase != nil {
+				err := k.Encrypt(passphrase)
+				command.Check(err)
+			}
+
 			// save the private key
 			filename := fmt.Sprintf("%s.rcl-key", k.Account)
-			err := SaveKeyToFile(k, filename)
+			err := keyfile.Save(k, filename)
 			command.Check(err)
 			command.Infof("Saved private key: %s", filename)
 			saves++
@@ -28,11 +28,9 @@ package main
 import (
 	"encoding/base64"
 	"encoding/gob"
-	"encoding/json"
 	"errors"
 	"flag"
 	"fmt"
-	"io/ioutil"
 	"log"
 	"os"
 
@@ -46,35 +44,6 @@ import (
 // Use `go get src.d10.dev/dumbdown` to fetch dumbdown tool.
 //go:generate sh -c "go doc | dumbdown > README.md"
 
-// Key is primarily used to marshal keys to/from files.
-type Key struct {
-	Account  data.Account `json:"address"`
-	Secret   string       `json:"secret"`
-	Nickname string       `json:"nickname"`
-}
-
-func SaveKeyToFile(key interface{}, filename string) error {
-	file, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0400)
-	if err != nil {
-		return err
-	}
-	defer file.Close()
-
-	enc := json.NewEncoder(file)
-	enc.SetIndent("", "\t")
-	err = enc.Encode(key)
-	return err
-}
-
-func ReadKeyFromFile(key interface{}, filename string) error {
-	b, err := ioutil.ReadFile(filename)
-	if err != nil {
-		return err
-	}
-	err = json.Unmarshal(b, key)
-	return err
-}
-
 var (
 	asFlag    *string
 	asAccount *data.Account
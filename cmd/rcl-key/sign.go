@@ -18,16 +18,90 @@
 //
 // Sign command expects an encoded unsigned transaction via stdin, and
 // encodes a signed transaction to stdout.
+//
+// When the signer's `.rcl-key` file is encrypted, sign prompts (on
+// the terminal, not stdin) for its passphrase.
+//
+// With -multi, sign adds a signature to the transaction's Signers,
+// rather than signing as the transacting account.  The signer must be
+// specified with -as, and be a member of the transacting account's
+// signer list.  For example, when two of three signers are required:
+//
+//   rcl-tx -as treasury -multisign=2 send ... > unsigned.json
+//   rcl-key -as alice sign -multi unsigned.json > alice.json
+//   rcl-key -as bob sign -multi unsigned.json > bob.json
+//   rcl-tx combine alice.json bob.json | rcl-tx submit
+//
+// Each signer may work on a separate machine.  Note the transaction
+// fee must account for the number of signers (see rcl-tx -multisign),
+// before any signatures are added.
+//
+// An account's regular key, if any, may be configured with
+// `regularkey=<address or nickname>` in the account's config section.
+// When that key's `.rcl-key` file is present, sign uses it in place
+// of the master key.
+//
+// Input may be JSON, or hex encoded blobs (one per line).  Output is
+// JSON, unless -format=blob.
+//
+// With -confirm, sign shows each transaction in plain language, using
+// nicknames, and asks (on the terminal, not stdin) whether to sign
+// it.  For example:
+//
+//   Payment of 100 USD.bitstamp from fund to hot, tag 12, sequence 5, fee 0.000012 XRP, expires ledger 123
+//
+// Risky properties, such as a partial payment, a fee above maxfee, no
+// LastLedgerSequence, or a flag like DisableMaster, are shown as
+// warnings.  When a transaction has an envelope (see rcl-tx
+// -envelope), who composed it, when, and its description are shown,
+// and the approval, signed by the signer's key, is noted in the
+// envelope.  Earlier approvals are shown only when signed by the key
+// of an account configured here; others are shown as unverified
+// claims.  Transactions not confirmed are not signed, and sign exits
+// with an error.
+//
+// When a policy file is present, `rcl-key.policy` in the current
+// directory or as specified by -policy, sign refuses transactions the
+// policy forbids, explaining why.  A policy restricts, per signing
+// account, transaction types, destinations, amounts per transaction
+// and per day, fee, memo and AccountSet flags.  For example:
+//
+//   [treasury]
+//   types = Payment, OfferCreate
+//   destinations = hot, bitstamp-usd
+//   maxamount = 1000/USD, 50000/XRP
+//   dailyamount = 5000/USD, 100000/XRP
+//   maxfee = 1000
+//   memo = required
+//   forbidflags = disablemaster, nofreeze
+//
+// Accounts are named by nickname or address.  An account without a
+// section may not sign, unless rules are given at the top of the file
+// (before any section), which then apply to it.  Currencies not
+// listed in maxamount or dailyamount may not be spent, unless the
+// list includes "*" (no limit on other currencies).  Amounts signed for
+// are recorded in `rcl-key.policy-state`, next to the key files, to
+// enforce daily limits.
+//
+// Each transaction signed, or refused, is noted in an audit log (see
+// the audit operation).
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
+	"time"
 
 	"src.d10.dev/command"
 
+	"github.com/dncohen/rcl/internal/cmd"
+	"github.com/dncohen/rcl/internal/keyfile"
 	"github.com/dncohen/rcl/internal/pipeline"
+	"github.com/dncohen/rcl/internal/policy"
 	"github.com/dncohen/rcl/util"
 	"github.com/rubblelabs/ripple/data"
 )
@@ -36,28 +110,100 @@ func init() {
 	command.RegisterOperation(command.Operation{
 		Handler:     opSign,
 		Name:        "sign",
-		Syntax:      "sign [<filename> ...]",
+		Syntax:      "sign [-multi] [-confirm] [-policy=<file>] [-format=json|blob] [<filename> ...]",
 		Description: `Sign RCL transactions.  Unsigned transactions are read from stdin or files.  Signed transactions are written to stdout.`,
 	})
 }
 
+var multiFlag *bool
+
+// default policy file, in the current directory
+const policyFilename = "rcl-key.policy"
+
 func opSign() error {
+	multiFlag = command.OperationFlagSet.Bool("multi", false, "add a multi-signature (signer specified by -as)")
+	confirmFlag := command.OperationFlagSet.Bool("confirm", false, "show each transaction, and ask before signing it")
+	policyFlag := command.OperationFlagSet.String("policy", "", fmt.Sprintf("file restricting what may be signed (default %q, if present)", policyFilename))
+	formatFlag := command.OperationFlagSet.String("format", pipeline.FormatJSON, fmt.Sprintf("format of signed transactions, %q or %q", pipeline.FormatJSON, pipeline.FormatBlob))
+
 	err := command.ParseOperationFlagSet()
 	if err != nil {
 		return err
 	}
 
+	pipeline.OutputFormat, err = pipeline.ParseFormat(*formatFlag)
+	if err != nil {
+		return err
+	}
+
+	if *multiFlag && asAccount == nil {
+		return errors.New("multi-signing requires signer address (-as=<address>)")
+	}
+
+	var signPolicy *policy.Policy
+	filename := *policyFlag
+	if filename == "" {
+		if _, err := os.Stat(policyFilename); err == nil {
+			filename = policyFilename
+		}
+	}
+	if filename != "" {
+		signPolicy, err = policy.Load(filename, resolveAccount)
+		command.Check(err)
+		command.V(1).Infof("signing according to policy %q", signPolicy)
+	}
+
 	argument := command.OperationFlagSet.Args()
 
-	unsignedIn := make(chan data.Transaction)
-	signedOut := make(chan data.Transaction)
+	unsignedIn := make(chan pipeline.Item)
+	signedOut := make(chan pipeline.Item)
 
 	go func() {
 		// sign all transactions in the pipeline
-		for unsigned := range unsignedIn {
-			signed, err := sign(unsigned)
+		for item := range unsignedIn {
+			unsigned := item.Transaction
+			var state *policy.State
+			policyDecision := ""
+			if signPolicy != nil {
+				var err error
+				state, err = policyState(signerOf(unsigned))
+				command.Check(err)
+				err = signPolicy.Check(unsigned, state)
+				if err != nil {
+					command.Check(auditSign(unsigned, nil, "not signed", fmt.Sprintf("forbidden: %s", err)))
+					base := unsigned.GetBase()
+					command.Error(fmt.Sprintf("%s (%s/%d) not signed, forbidden by policy: %s", unsigned.GetType(), cmd.FormatAccount(base.Account, nil), base.Sequence, err))
+					continue
+				}
+				policyDecision = "allowed"
+			}
+			if *confirmFlag {
+				ok, err := review(item)
+				command.Check(err)
+				base := unsigned.GetBase()
+				if !ok {
+					command.Check(auditSign(unsigned, nil, "not confirmed", policyDecision))
+					command.Error(fmt.Sprintf("%s (%s/%d) not confirmed, not signed", unsigned.GetType(), cmd.FormatAccount(base.Account, nil), base.Sequence))
+					continue
+				}
+				if env := item.Envelope; env != nil {
+					signer := signerOf(unsigned)
+					kp, err := keypair(signer)
+					command.Check(err)
+					approval, err := pipeline.SignApproval(unsigned, signer, kp, "confirmed with rcl-key sign -confirm")
+					command.Check(err)
+					env.Approvals = append(env.Approvals, *approval)
+				}
+			}
+			err := sign(&item)
 			command.Check(err)
-			signedOut <- signed
+			signed := item.Transaction
+			command.Check(auditSign(unsigned, signed, "signed", policyDecision))
+			if state != nil {
+				err = state.Record(signed)
+				command.Check(err)
+			}
+			signedOut <- item
 		}
 		close(signedOut)
 	}()
@@ -69,63 +215,221 @@ func opSign() error {
 			command.Check(err)
 		} else {
 			// read files
-			for _, arg := range argument {
-				match, err := filepath.Glob(arg)
-				command.Check(err)
-				if len(match) == 0 {
-					command.Check(fmt.Errorf("expected transaction: file not found (%q)", arg))
-				}
-				for _, fname := range match {
-
-					func() { // for defer
-						f, err := os.Open(fname)
-						command.Check(err)
-						defer f.Close()
-
-						err = pipeline.DecodeInput(unsignedIn, f)
-						command.Check(err)
-					}()
-				}
-			}
-			// end of files
+			err := pipeline.DecodeFiles(unsignedIn, argument...)
+			command.Check(err)
 		}
 		// files or stdin has been read
 		close(unsignedIn)
 	}()
 
 	// the goroutines (above) produce signed transactions.  here we write them to stdout
-	err = pipeline.EncodeOutput(os.Stdout, signedOut)
+	err = pipeline.EncodeItems(os.Stdout, signedOut)
 	command.Check(err)
 
 	return nil
 }
 
-var keycache = make(map[data.Account]*Key)
+var keycache = make(map[data.Account]*keyfile.Key)
+
+// resolveAccount converts a nickname or address in a policy file.
+func resolveAccount(name string) (data.Account, error) {
+	tmp, err := cmd.ParseAccountArg([]string{name})
+	if err != nil {
+		return data.Account{}, err
+	}
+	return tmp[0].Account, nil
+}
+
+// policy state files, by directory
+var stateCache = make(map[string]*policy.State)
+
+// policyState opens the state file next to a signer's key file.
+func policyState(signer data.Account) (*policy.State, error) {
+	keyfile, err := keyFilename(signer)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(keyfile)
+	state, ok := stateCache[dir]
+	if !ok {
+		state, err = policy.OpenState(filepath.Join(dir, "rcl-key.policy-state"))
+		if err != nil {
+			return nil, err
+		}
+		stateCache[dir] = state
+	}
+	return state, nil
+}
 
-func sign(unsigned data.Transaction) (data.Transaction, error) {
-	var signer data.Account
+// signerOf returns the account which signs a transaction, either
+// -as or the transacting account.
+func signerOf(unsigned data.Transaction) data.Account {
 	if *asFlag != "" {
-		signer = *asAccount
-	} else {
-		// learn signer from transaction
-		signer = unsigned.GetBase().Account
+		return *asAccount
+	}
+	// learn signer from transaction
+	return unsigned.GetBase().Account
+}
+
+// review shows a transaction in plain language, on the terminal, and
+// asks whether to sign it.
+func review(item pipeline.Item) (bool, error) {
+	t, env := item.Transaction, item.Envelope
+
+	// Nicknames configured here are trusted.  Those from the envelope
+	// are shown only as the composer's.
+	name := func(account data.Account) string {
+		nick := cmd.FormatAccount(account, nil)
+		if nick == account.String() && env != nil {
+			if composed, ok := env.Nicknames[account.String()]; ok {
+				return fmt.Sprintf("%s (%q per composer)", account, composed)
+			}
+		}
+		return nick
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n%s\n", cmd.DescribeTransaction(t, name))
+
+	warning := cmd.TransactionWarnings(t)
+	if env != nil {
+		composed := ""
+		if env.Composed != nil {
+			composed = " at " + env.Composed.Local().Format(time.RFC3339)
+		}
+		fmt.Fprintf(&b, "  Composed by %s%s, for %s.\n", env.Composer, composed, env.Network)
+		if env.Description != "" {
+			fmt.Fprintf(&b, "  Description: %s\n", env.Description)
+		}
+		for _, approval := range env.Approvals {
+			at := approval.Time.Local().Format(time.RFC3339)
+			account, err := verifyApproval(t, approval)
+			if err != nil {
+				fmt.Fprintf(&b, "  UNVERIFIED claim: approved by %s at %s (%s).\n", approval.Account, at, err)
+				continue
+			}
+			fmt.Fprintf(&b, "  Approved by %s at %s.\n", cmd.FormatAccount(account, nil), at)
+		}
+
+		var addresses []string
+		for address := range env.Nicknames {
+			addresses = append(addresses, address)
+		}
+		sort.Strings(addresses)
+		for _, address := range addresses {
+			account, err := data.NewAccountFromAddress(address)
+			if err != nil {
+				return false, fmt.Errorf("bad address in envelope nicknames: %w", err)
+			}
+			nick := cmd.FormatAccount(*account, nil)
+			if nick != address && nick != env.Nicknames[address] {
+				warning = append(warning, fmt.Sprintf("composer calls %s %q, but it is %q here", address, env.Nicknames[address], nick))
+			}
+		}
+	}
+	for _, w := range warning {
+		fmt.Fprintf(&b, "  WARNING: %s\n", w)
 	}
+	b.WriteString("Sign? (yes/no) ")
+
+	return cmd.Confirm(b.String())
+}
 
+// verifyApproval checks an approval in an envelope, which comes from
+// the composer, so is not trusted.  The approval must be signed by
+// the master or regular key of a known account, that is, one in
+// configuration here.
+func verifyApproval(t data.Transaction, approval pipeline.Approval) (data.Account, error) {
+	account, err := data.NewAccountFromAddress(approval.Account)
+	if err != nil {
+		return data.Account{}, fmt.Errorf("bad address: %w", err)
+	}
+	if _, ok := cmd.AccountConfig(*account, nil); !ok {
+		return data.Account{}, errors.New("account not configured here")
+	}
+	pubkey, err := pipeline.VerifyApproval(t, approval)
+	if err != nil {
+		return data.Account{}, err
+	}
+	err = verifyKey(*account, *pubkey)
+	if err != nil {
+		return data.Account{}, err
+	}
+	return *account, nil
+}
+
+// keypair loads the key which signs for signer.
+func keypair(signer data.Account) (util.Keypair, error) {
 	k, ok := keycache[signer]
 	if !ok {
-		// TODO(dnc): check current directory and also config directory.
-		filename := fmt.Sprintf("%s.rcl-key", signer)
-		k = &Key{}
-		err := ReadKeyFromFile(k, filename)
+		filename, err := keyFilename(signer)
+		command.Check(err)
+		k, err = cmd.LoadKey(filename)
 		command.Check(err)
 		keycache[signer] = k // cache for signing multiple tx
 	}
 
-	kp, err := util.NewEcdsaFromSecret(k.Secret)
+	kp, err := cmd.Keypair(k)
 	if err != nil {
-		return nil, err
+		return kp, err
+	}
+	if kp.Address != k.Account.String() {
+		return kp, fmt.Errorf("secret does not match address %s", k.Account)
+	}
+	return kp, nil
+}
+
+// sign signs an item's transaction, or with -multi adds a signature to
+// its Signers.
+func sign(item *pipeline.Item) error {
+	unsigned := item.Transaction
+	signer := signerOf(unsigned)
+	kp, err := keypair(signer)
+	if err != nil {
+		return err
 	}
 
-	err = kp.Sign(unsigned)
-	return unsigned, err
+	if !*multiFlag {
+		return kp.Sign(unsigned)
+	}
+	s, err := kp.MultiSign(unsigned, signer)
+	if err != nil {
+		return err
+	}
+	item.Signers, err = util.AddSigners(unsigned, item.Signers, s)
+	return err
+}
+
+// keyFilename finds the key file used to sign for an account.  When a
+// regular key is configured (see `regularkey=` in the account's
+// config section) and its key file is present, it is used.
+// Otherwise, the account's master key.
+func keyFilename(signer data.Account) (string, error) {
+	// TODO(dnc): check current directory and also config directory.
+	master := fmt.Sprintf("%s.rcl-key", signer)
+
+	regular, err := cmd.RegularKey(signer)
+	if err != nil {
+		return "", err
+	}
+	if regular == nil {
+		return master, nil
+	}
+
+	filename := fmt.Sprintf("%s.rcl-key", regular)
+	_, err = os.Stat(filename)
+	if err == nil {
+		command.V(1).Infof("signing for %s with regular key %s", cmd.FormatAccount(signer, nil), regular)
+		return filename, nil
+	}
+	if !os.IsNotExist(err) {
+		return "", err
+	}
+
+	// regular key not available on this machine
+	_, err = os.Stat(master)
+	if err != nil {
+		return "", fmt.Errorf("no key file for %s (regular key %s) or %s (master key)", regular, filename, master)
+	}
+	return master, nil
 }
@@ -0,0 +1,164 @@
+// Copyright (C) 2020  David N. Cohen
+// This file is part of github.com/dncohen/rcl
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Command rcl-key - Operation verify
+//
+// Verify checks signed transactions, offline, before they are
+// submitted.  Transactions are read from stdin or files, like sign.
+// Those which pass are written to stdout, so verify may be placed
+// between signing and submitting:
+//
+//   rcl-key verify signed.json | rcl-tx submit
+//
+// For each transaction, verify checks the signature (secp256k1 or
+// ed25519) against the transaction's SigningPubKey, or each signature
+// of a multi-signed transaction.  It checks that each key is the
+// signing account's master key, or its regular key as configured with
+// `regularkey=` (see sign).  And it recomputes the transaction hash.
+// Each transaction is reported as passing or failing.  Any failure
+// results in non-zero exit status.
+//
+// Note verify cannot check, offline, that the master key is enabled,
+// or that multi-signers are in the account's signer list.
+//
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/dncohen/rcl/internal/cmd"
+	"github.com/dncohen/rcl/internal/pipeline"
+	"github.com/dncohen/rcl/util"
+	"github.com/rubblelabs/ripple/data"
+	"src.d10.dev/command"
+)
+
+func init() {
+	command.RegisterOperation(command.Operation{
+		Handler:     opVerify,
+		Name:        "verify",
+		Syntax:      "verify [-format=json|blob] [<filename> ...]",
+		Description: "Verify the signatures and hashes of signed transactions.  Transactions which pass are written to stdout.",
+	})
+}
+
+func opVerify() error {
+	formatFlag := command.OperationFlagSet.String("format", pipeline.FormatJSON, fmt.Sprintf("format of verified transactions, %q or %q", pipeline.FormatJSON, pipeline.FormatBlob))
+
+	err := command.ParseOperationFlagSet()
+	if err != nil {
+		return err
+	}
+
+	pipeline.OutputFormat, err = pipeline.ParseFormat(*formatFlag)
+	if err != nil {
+		return err
+	}
+
+	argument := command.OperationFlagSet.Args()
+
+	signedIn := make(chan pipeline.Item)
+	verifiedOut := make(chan pipeline.Item)
+
+	go func() {
+		for item := range signedIn {
+			t := item.Transaction
+			base := t.GetBase()
+			hash := *t.GetHash()
+			err := verify(item)
+			if err != nil {
+				command.Error(fmt.Sprintf("fail: %s %s (%s/%d): %s", t.GetType(), hash, cmd.FormatAccount(base.Account, nil), base.Sequence, err))
+				continue
+			}
+			command.Infof("pass: %s %s (%s/%d)", t.GetType(), hash, cmd.FormatAccount(base.Account, nil), base.Sequence)
+			verifiedOut <- item
+		}
+		close(verifiedOut)
+	}()
+
+	go func() {
+		if len(argument) == 0 {
+			err := pipeline.DecodeInput(signedIn, os.Stdin)
+			command.Check(err)
+		} else {
+			err := pipeline.DecodeFiles(signedIn, argument...)
+			command.Check(err)
+		}
+		close(signedIn)
+	}()
+
+	err = pipeline.EncodeItems(os.Stdout, verifiedOut)
+	command.Check(err)
+
+	return nil
+}
+
+// verify checks a transaction's signatures, signing keys and hash.
+func verify(item pipeline.Item) error {
+	t := item.Transaction
+	base := t.GetBase()
+	if len(item.Signers) > 0 {
+		err := util.VerifySigners(t, item.Signers)
+		if err != nil {
+			return err
+		}
+		for _, s := range item.Signers {
+			err = verifyKey(s.Signer.Account, s.Signer.SigningPubKey)
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		err := util.VerifySignature(t)
+		if err != nil {
+			return err
+		}
+		err = verifyKey(base.Account, *base.SigningPubKey)
+		if err != nil {
+			return err
+		}
+	}
+
+	hash, _, err := data.Raw(item.Full())
+	if err != nil {
+		return err
+	}
+	if hash != *t.GetHash() {
+		return fmt.Errorf("hash %s does not match transaction (expected %s)", t.GetHash(), hash)
+	}
+	return nil
+}
+
+// verifyKey checks that a public key may sign for an account, as its
+// master key or configured regular key.
+func verifyKey(account data.Account, pubkey data.PublicKey) error {
+	signer := util.PublicKeyAccount(pubkey)
+	if signer == account {
+		return nil
+	}
+	regular, err := cmd.RegularKey(account)
+	if err != nil {
+		return err
+	}
+	if regular != nil && signer == *regular {
+		return nil
+	}
+	if regular == nil {
+		return fmt.Errorf("signed by %s, not master key of %s (and no regular key configured)", signer, cmd.FormatAccount(account, nil))
+	}
+	return fmt.Errorf("signed by %s, not master key or regular key (%s) of %s", signer, cmd.FormatAccount(*regular, nil), cmd.FormatAccount(account, nil))
+}
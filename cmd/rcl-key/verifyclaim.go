@@ -0,0 +1,100 @@
+// Copyright (C) 2020  David N. Cohen
+// This file is part of github.com/dncohen/rcl
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Command rcl-key - Operation verify-claim
+//
+// Verify-claim checks the signature of a payment channel claim,
+// offline.  The claim is read from a file or stdin, in the format
+// written by the claim operation.
+//
+//   rcl-key verify-claim -pubkey=<channel public key> claim.json
+//
+// The signature is checked against the channel's public key, given by
+// -pubkey, either in hex or as an address or nickname with `pubkey=`
+// in its config section.  Without -pubkey, the key configured for the
+// -as account is used.  (The key included in the claim itself is not
+// trusted.)  A bad signature results in an error and non-zero exit
+// status.
+//
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/dncohen/rcl/internal/cmd"
+	"github.com/dncohen/rcl/util"
+	"src.d10.dev/command"
+)
+
+func init() {
+	command.RegisterOperation(command.Operation{
+		Handler:     opVerifyClaim,
+		Name:        "verify-claim",
+		Syntax:      "verify-claim [-pubkey=<key>] [<filename>]",
+		Description: "Verify the signature of a payment channel claim.",
+	})
+}
+
+func opVerifyClaim() error {
+	pubkeyFlag := command.OperationFlagSet.String("pubkey", "", "channel public key, hex or address/nickname with configured pubkey")
+
+	err := command.ParseOperationFlagSet()
+	if err != nil {
+		return err
+	}
+
+	pubkeyArg := *pubkeyFlag
+	if pubkeyArg == "" {
+		if asAccount == nil {
+			return errors.New("verify-claim requires -pubkey=<key> (or -as=<address>)")
+		}
+		pubkeyArg = asAccount.String()
+	}
+	pubkey, err := cmd.PublicKeyArg(pubkeyArg)
+	if err != nil {
+		return err
+	}
+
+	argument := command.OperationFlagSet.Args()
+	var b []byte
+	switch len(argument) {
+	case 0:
+		b, err = ioutil.ReadAll(os.Stdin)
+	case 1:
+		b, err = ioutil.ReadFile(argument[0])
+	default:
+		return errors.New("expected at most one claim file")
+	}
+	if err != nil {
+		return err
+	}
+
+	claim := &util.Claim{}
+	err = json.Unmarshal(b, claim)
+	if err != nil {
+		return fmt.Errorf("failed to parse claim: %w", err)
+	}
+
+	err = util.VerifyClaim(claim, *pubkey)
+	command.Check(err)
+
+	command.Infof("valid claim of %s on channel %s", claim.Amount, claim.Channel)
+	return nil
+}
@@ -0,0 +1,364 @@
+// Copyright (C) 2020  David N. Cohen
+// This file is part of github.com/dncohen/rcl
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Operation batch
+//
+// Batch composes a payment for each row of a CSV file.  Columns are
+// beneficiary, amount, and optionally memo and invoice ID:
+//
+//     # beneficiary,amount,memo,invoice
+//     supplier,1250/USD/bitstamp,June services,INV-1234
+//     rN7n7otQDd6FczFgLdSqtcsAUxDkw6fzRH.42,100/XRP,,
+//
+// The beneficiary is a nickname or address, optionally followed by a
+// destination tag (".<tag>").  An invoice ID is hex, or any other
+// text, which is hashed.  Lines beginning "#" are ignored, as is a
+// header row beginning "beneficiary".
+//
+//     rcl-tx -as treasury batch payments.csv > unsigned.json
+//
+// Before composing, batch checks each destination's requirements: a
+// destination tag, when the account requires one; authorization, when
+// it has DepositAuth enabled; and a trust line with enough room, for
+// issuances (enough for all the batch's payments to that destination,
+// together).  When any row fails these checks, no transactions are
+// composed.
+//
+// Payments have consecutive sequence numbers and share one
+// LastLedgerSequence, which allows -window ledgers (by default, more
+// for larger batches) to sign and submit all of them.  Totals per
+// currency are shown, for review before signing.
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"golang.org/x/sync/errgroup"
+	"src.d10.dev/command"
+
+	"github.com/dncohen/rcl/internal/cmd"
+	"github.com/dncohen/rcl/internal/pipeline"
+	"github.com/dncohen/rcl/rpc"
+	"github.com/dncohen/rcl/tx"
+	"github.com/pkg/errors"
+	"github.com/rubblelabs/ripple/data"
+	"github.com/rubblelabs/ripple/websockets"
+)
+
+// AccountRoot flags, see https://xrpl.org/accountroot.html
+const (
+	lsfRequireDestTag = 0x00020000
+	lsfDepositAuth    = 0x01000000
+)
+
+func init() {
+	command.RegisterOperation(command.Operation{
+		Handler:     opBatch,
+		Name:        "batch",
+		Syntax:      "batch [-window=<ledgers>] <file.csv>",
+		Description: `Compose payments from rows of a CSV file.`,
+	})
+}
+
+// batchRow is one payment of a batch.
+type batchRow struct {
+	row         int // in CSV file, for error messages
+	beneficiary cmd.AccountTag
+	amount      *data.Amount
+	memo        string
+	invoice     string
+}
+
+func opBatch() error {
+	windowFlag := command.OperationFlagSet.Int("window", 0, "ledgers in which to submit the batch (default based on number of payments)")
+
+	argument, err := cmd.ParseInterspersed(command.OperationFlagSet, command.Args()[1:])
+	command.CheckUsage(err)
+	if len(argument) != 1 {
+		command.CheckUsage(errors.New("expected argument: <file.csv>"))
+	}
+
+	// -as <account> is parsed in main.go
+	if asAccount == nil {
+		command.CheckUsage(errors.New("operation requires -as <account> flag"))
+	}
+
+	f, err := os.Open(argument[0])
+	command.Check(err)
+	rows, err := readBatch(f)
+	f.Close()
+	command.Check(err)
+	if len(rows) == 0 {
+		command.Check(fmt.Errorf("no payments in %s", argument[0]))
+	}
+
+	window := *windowFlag
+	if window <= 0 {
+		window = LedgerSequenceInterval + len(rows)/10
+	}
+
+	rippled, err := cmd.Rippled()
+	command.Check(err)
+
+	remote, err := websockets.NewRemote(rippled)
+	if err != nil {
+		command.Check(fmt.Errorf("failed to connect to %q: %w", rippled, err))
+	}
+	defer remote.Close()
+
+	ws, err := rpc.NewWebsocket(rippled)
+	command.Check(err)
+	defer ws.Close()
+
+	var g errgroup.Group
+	var accountInfo *websockets.AccountInfoResult
+	g.Go(func() error {
+		var err error
+		accountInfo, err = remote.AccountInfo(*asAccount)
+		if err != nil {
+			return fmt.Errorf("failed to get account_info %s: %w", asAccount, err)
+		}
+		return nil
+	})
+	g.Go(func() error {
+		fail := false
+		issued := issuedTotals(rows)
+		checked := make(map[string]bool) // trust lines
+		for _, row := range rows {
+			err := checkDestination(remote, ws, row)
+			if key := issuedKey(row); err == nil && key != "" && !checked[key] {
+				checked[key] = true
+				err = checkTrustLine(remote, row, issued[key])
+			}
+			if err != nil {
+				command.Errorf("%s row %d: %s", argument[0], row.row, err)
+				fail = true
+			}
+		}
+		if fail {
+			return errors.New("destination requirements not met, no payments composed")
+		}
+		return nil
+	})
+	err = g.Wait()
+	command.Check(err)
+
+	// Prepare to encode transaction output.
+	unsignedOut := make(chan (data.Transaction))
+	g.Go(func() error {
+		return pipeline.EncodeOutput(os.Stdout, unsignedOut)
+	})
+
+	totals := make(map[string]*data.Amount)
+	var assets []string // in order of appearance
+	sequence := *accountInfo.AccountData.Sequence
+	lastLedger := accountInfo.LedgerSequence + uint32(window)
+	fee := txFee()
+	for _, row := range rows {
+		beneficiaryTag := &row.beneficiary.Tag
+		if *beneficiaryTag == 0 {
+			beneficiaryTag = nil
+		}
+		memo := row.memo
+		if memo == "" {
+			memo = *memoFlag
+		}
+		var sendMax *data.Amount
+		if !row.amount.IsNative() { // No sendmax on XRP payments
+			sendMax = row.amount
+		}
+		options := []func(data.Transaction) error{
+			tx.SetAddress(asAccount),
+			tx.SetSourceTag(asTag),
+			tx.SetSequence(sequence),
+			tx.SetLastLedgerSequence(lastLedger),
+			tx.SetFee(fee),
+
+			tx.AddMemo(memo),
+			tx.AddMemo(memohex),
+
+			tx.SetAmount(row.amount),
+			tx.SetSendMax(sendMax),
+			tx.SetDestination(row.beneficiary.Account),
+			tx.SetDestinationTag(beneficiaryTag),
+
+			tx.SetCanonicalSig(true),
+		}
+		if row.invoice != "" {
+			options = append(options, tx.SetInvoiceID(invoiceID(row.invoice)))
+		}
+		t, err := tx.NewPayment(options...)
+		if err != nil {
+			command.Check(fmt.Errorf("%s row %d: failed to prepare payment: %w", argument[0], row.row, err))
+		}
+		unsignedOut <- t
+		sequence++
+
+		asset := fmt.Sprint(row.amount.Asset())
+		if total, ok := totals[asset]; ok {
+			sum, err := total.Add(row.amount)
+			command.Check(err)
+			totals[asset] = sum
+		} else {
+			totals[asset] = row.amount
+			assets = append(assets, asset)
+		}
+	}
+	close(unsignedOut)
+
+	err = g.Wait()
+	command.Check(err)
+
+	// Summary, for review before signing.
+	table := tabwriter.NewWriter(os.Stderr, 0, 0, 1, ' ', tabwriter.Debug)
+	fmt.Fprintf(table, "Currency\t Total\t\n")
+	for _, asset := range assets {
+		fmt.Fprintf(table, "%s\t %s\t\n", asset, totals[asset])
+	}
+	table.Flush()
+	command.Infof("Prepared %d payments by %s, sequence %d to %d, LastLedgerSequence %d, fees %d drops.",
+		len(rows), asAccount, *accountInfo.AccountData.Sequence, sequence-1, lastLedger, fee*len(rows))
+
+	return nil
+}
+
+// readBatch parses CSV rows of beneficiary, amount, memo and invoice.
+func readBatch(in io.Reader) ([]batchRow, error) {
+	r := csv.NewReader(in)
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	var rows []batchRow
+	for n := 1; ; n++ {
+		record, err := r.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if n == 1 && strings.EqualFold(record[0], "beneficiary") {
+			continue // header
+		}
+		if len(record) < 2 || len(record) > 4 {
+			return nil, fmt.Errorf("row %d: expected beneficiary, amount, memo, invoice; got %d fields", n, len(record))
+		}
+		row := batchRow{row: n}
+		beneficiary, err := cmd.ParseAccountArg(record[0:1])
+		if err != nil {
+			return nil, fmt.Errorf("row %d: bad beneficiary: %w", n, err)
+		}
+		row.beneficiary = beneficiary[0]
+		row.amount, err = cmd.AmountFromArg(record[1])
+		if err != nil {
+			return nil, fmt.Errorf("row %d: bad amount (%q): %w", n, record[1], err)
+		}
+		if !row.amount.IsNative() && row.amount.Issuer == zeroAccount {
+			row.amount.Issuer = row.beneficiary.Account
+		}
+		if len(record) > 2 {
+			row.memo = record[2]
+		}
+		if len(record) > 3 {
+			row.invoice = record[3]
+		}
+		rows = append(rows, row)
+	}
+}
+
+// issuedKey identifies the trust line a row pays through, or is empty
+// when the row pays XRP, or the destination's own issuance.
+func issuedKey(row batchRow) string {
+	if row.amount.IsNative() || row.amount.Issuer == row.beneficiary.Account {
+		return ""
+	}
+	return fmt.Sprintf("%s %s", row.beneficiary.Account, row.amount.Asset())
+}
+
+// issuedTotals sums the rows paid through each trust line, as trust
+// lines must have room for all of them.
+func issuedTotals(rows []batchRow) map[string]*big.Rat {
+	total := make(map[string]*big.Rat)
+	for _, row := range rows {
+		key := issuedKey(row)
+		if key == "" {
+			continue
+		}
+		if _, ok := total[key]; !ok {
+			total[key] = new(big.Rat)
+		}
+		total[key].Add(total[key], row.amount.Value.Rat())
+	}
+	return total
+}
+
+// checkDestination returns an error when a payment would fail, because
+// of the destination's requirements.
+func checkDestination(remote *websockets.Remote, ws *rpc.Websocket, row batchRow) error {
+	destination := row.beneficiary.Account
+	info, err := remote.AccountInfo(destination)
+	if err != nil {
+		if strings.Contains(err.Error(), "actNotFound") && row.amount.IsNative() {
+			return nil // XRP payment may create the account
+		}
+		return fmt.Errorf("account_info failed for %s: %w", destination, err)
+	}
+
+	var flags uint32
+	if info.AccountData.Flags != nil {
+		flags = uint32(*info.AccountData.Flags)
+	}
+	if flags&lsfRequireDestTag != 0 && row.beneficiary.Tag == 0 {
+		return fmt.Errorf("%s requires a destination tag", destination)
+	}
+	if flags&lsfDepositAuth != 0 {
+		authorized, err := ws.DepositAuthorized(*asAccount, destination)
+		if err != nil {
+			return err
+		}
+		if !authorized {
+			return fmt.Errorf("%s has DepositAuth enabled, and has not authorized %s", destination, asAccount)
+		}
+	}
+	return nil
+}
+
+// checkTrustLine returns an error when the destination's trust line
+// lacks room for total, the sum of the batch's payments through it.
+func checkTrustLine(remote *websockets.Remote, row batchRow, total *big.Rat) error {
+	destination := row.beneficiary.Account
+	lines, err := remote.AccountLines(destination, "current")
+	if err != nil {
+		return fmt.Errorf("account_lines failed for %s: %w", destination, err)
+	}
+	for _, line := range lines.Lines {
+		if line.Account == row.amount.Issuer && line.Currency == row.amount.Currency {
+			room := new(big.Rat).Sub(line.Limit.Rat(), line.Balance.Rat())
+			if room.Cmp(total) < 0 {
+				return fmt.Errorf("%s trust line limit (%s) insufficient for %s/%s paid in this batch (balance %s)", destination, line.Limit, total.FloatString(6), row.amount.Asset(), line.Balance)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("%s has no trust line for %s", destination, row.amount.Asset())
+}
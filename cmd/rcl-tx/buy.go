@@ -0,0 +1,46 @@
+// Copyright (C) 2020  David N. Cohen
+// This file is part of github.com/dncohen/rcl
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Operation buy
+//
+// Create an offer to buy an asset or issuance.  Specify either both
+// amounts, or the amount to buy and a price per unit:
+//
+//     rcl-tx -as desk buy 100/XRP for 52/USD/bitstamp
+//     rcl-tx -as desk buy 100/XRP at 0.52 USD/bitstamp
+//
+// Unlike a sell offer, a buy offer is filled when the amount to buy
+// is acquired, even if that costs less than offered.  Buy accepts the
+// same flags as sell (-ioc, -fok, -passive, -expires and -replace),
+// see `rcl-tx sell -help`.
+package main
+
+import (
+	"src.d10.dev/command"
+)
+
+func init() {
+	command.RegisterOperation(command.Operation{
+		Handler:     opBuy,
+		Name:        "buy",
+		Syntax:      "buy <amount> for <amount> | buy <amount> at <price> <currency>[/<issuer>] [-ioc|-fok] [-passive] [-expires=<time>] [-replace=<sequence>]",
+		Description: `Create an offer to buy one asset or issuance with another.`,
+	})
+}
+
+func opBuy() error {
+	return opOffer(false)
+}
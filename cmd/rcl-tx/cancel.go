@@ -99,13 +99,9 @@ func opCancel() error {
 
 	if *allFlag {
 		// Cancel all of an account's outstanding offers.
-		result, err := remote.AccountOffers(*asAccount, "current")
-		if err != nil {
-			command.Check(fmt.Errorf("account_offers failed for %s: %w", asAccount, err))
-		}
-		for _, offer := range result.Offers {
-			seqs = append(seqs, offer.Sequence)
-		}
+		all, err := offerSequences(remote, *asAccount, nil)
+		command.Check(err)
+		seqs = append(seqs, all...)
 	}
 
 	if len(seqs) < 1 {
@@ -158,17 +154,8 @@ func opCancel() error {
 	// Prepare transactions.
 	sequence := *accountInfo.AccountData.Sequence
 	for _, offerSeq := range seqs {
-		t, err := tx.NewOfferCancel(
-			tx.SetAddress(asAccount),
-			tx.SetSequence(sequence),
-			tx.SetLastLedgerSequence(accountInfo.LedgerSequence+LedgerSequenceInterval),
-			tx.SetFee(12),
-			tx.SetOfferSequence(offerSeq),
-			tx.SetCanonicalSig(true),
-		)
-		if err != nil {
-			command.Check(fmt.Errorf("failed to prepare OfferCancel: %w", err))
-		}
+		t, err := newOfferCancel(sequence, accountInfo.LedgerSequence, offerSeq)
+		command.Check(err)
 		sequence++
 		// TODO: is it necessary to clean up the hash that rubblelabs puts into unsigned tx?
 		// "hash":"0000000000000000000000000000000000000000000000000000000000000000"
@@ -193,3 +180,37 @@ func opCancel() error {
 	return nil
 
 }
+
+// offerSequences returns the sequence numbers of an account's
+// outstanding offers, those for which match returns true (or all,
+// when match is nil).
+func offerSequences(remote *websockets.Remote, account data.Account, match func(data.AccountOffer) bool) ([]uint32, error) {
+	result, err := remote.AccountOffers(account, "current")
+	if err != nil {
+		return nil, fmt.Errorf("account_offers failed for %s: %w", account, err)
+	}
+	var seqs []uint32
+	for _, offer := range result.Offers {
+		if match == nil || match(offer) {
+			seqs = append(seqs, offer.Sequence)
+		}
+	}
+	return seqs, nil
+}
+
+// newOfferCancel composes an OfferCancel, by the -as account, with
+// the given account sequence.
+func newOfferCancel(sequence, ledgerSequence, offerSeq uint32) (*data.OfferCancel, error) {
+	t, err := tx.NewOfferCancel(
+		tx.SetAddress(asAccount),
+		tx.SetSequence(sequence),
+		tx.SetLastLedgerSequence(ledgerSequence+LedgerSequenceInterval),
+		tx.SetFee(txFee()),
+		tx.SetOfferSequence(offerSeq),
+		tx.SetCanonicalSig(true),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare OfferCancel: %w", err)
+	}
+	return t, nil
+}
@@ -0,0 +1,337 @@
+// Copyright (C) 2020  David N. Cohen
+// This file is part of github.com/dncohen/rcl
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Operation channel
+//
+// Compose transactions to open, fund, claim from, or close a payment
+// channel.  A channel sets aside XRP, which the source pays to the
+// destination by signing claims off-ledger (see `rcl-key claim`).
+// The destination later redeems the best claim on-ledger.
+//
+//   rcl-tx -as treasury channel open vendor 100/XRP -settle-delay=1d
+//   rcl-tx -as treasury channel fund <channel ID> 50/XRP
+//   rcl-key -as treasury claim <channel ID> 10/XRP > claim.json
+//   rcl-tx -as vendor channel claim <channel ID> claim.json
+//   rcl-tx -as treasury channel close <channel ID>
+//
+// The channel's public key is that of the key which will sign claims.
+// By default, open uses `pubkey=` from the config section of the -as
+// account (written by `rcl-key backup`).  Otherwise, specify
+// `-pubkey=<hex or nickname>`.
+//
+// Claim reads a claim from a file (or stdin), and checks it against
+// the channel before composing a transaction.  With -close, the
+// destination also closes the channel.  When the source closes a
+// channel, it remains open for the settle delay, giving the
+// destination time to claim.
+//
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"src.d10.dev/command"
+
+	"github.com/dncohen/rcl/internal/cmd"
+	"github.com/dncohen/rcl/internal/pipeline"
+	"github.com/dncohen/rcl/rpc"
+	"github.com/dncohen/rcl/tx"
+	"github.com/dncohen/rcl/util"
+	"github.com/pkg/errors"
+	"github.com/rubblelabs/ripple/data"
+	"github.com/rubblelabs/ripple/websockets"
+)
+
+func init() {
+	command.RegisterOperation(command.Operation{
+		Handler:     opChannel,
+		Name:        "channel",
+		Syntax:      "channel open <destination> <amount> [-settle-delay=<duration>] [-cancel-after=<time>] [-pubkey=<key>] | channel fund <id> <amount> [-expiration=<time>] | channel claim <id> [<claim file>] [-close] | channel close <id>",
+		Description: `Open, fund, claim from or close a payment channel.`,
+	})
+}
+
+func opChannel() error {
+	settleDelayFlag := command.OperationFlagSet.String("settle-delay", "24h", "(open) time the source must wait to close a channel with unclaimed XRP")
+	cancelAfterFlag := command.OperationFlagSet.String("cancel-after", "", "(open) time after which the channel expires")
+	pubkeyFlag := command.OperationFlagSet.String("pubkey", "", "(open) public key of claims, hex or nickname (default from config of -as account)")
+	expirationFlag := command.OperationFlagSet.String("expiration", "", "(fund) new expiration of the channel")
+	closeFlag := command.OperationFlagSet.Bool("close", false, "(claim) also close the channel")
+
+	// flags may follow positional args
+	argument, err := cmd.ParseInterspersed(command.OperationFlagSet, command.Args()[1:])
+	command.CheckUsage(err)
+
+	if len(argument) < 1 {
+		command.CheckUsage(errors.New("expected open, fund, claim or close"))
+	}
+	subcommand := argument[0]
+	argument = argument[1:]
+
+	switch subcommand {
+	case "open", "fund":
+		if len(argument) != 2 {
+			command.CheckUsage(fmt.Errorf("channel %s requires two arguments", subcommand))
+		}
+	case "claim":
+		if len(argument) < 1 || len(argument) > 2 {
+			command.CheckUsage(errors.New("channel claim requires <id> and optional claim file"))
+		}
+	case "close":
+		if len(argument) != 1 {
+			command.CheckUsage(errors.New("channel close requires <id> argument"))
+		}
+	default:
+		command.CheckUsage(fmt.Errorf("expected open, fund, claim or close, got %q", subcommand))
+	}
+
+	// -as <account> is parsed in main.go
+	if asAccount == nil {
+		return errors.New("operation requires -as <account> flag")
+	}
+
+	rippled, err := cmd.Rippled()
+	command.Check(err)
+
+	var options []func(data.Transaction) error
+	switch subcommand {
+	case "open":
+		options, err = channelOpenOptions(argument, *settleDelayFlag, *cancelAfterFlag, *pubkeyFlag)
+	case "fund":
+		options, err = channelFundOptions(argument, *expirationFlag)
+	case "claim":
+		options, err = channelClaimOptions(rippled, argument, *closeFlag)
+	case "close":
+		var id *data.Hash256
+		id, err = data.NewHash256(argument[0])
+		if err == nil {
+			options = append(options, tx.SetChannel(*id), tx.SetClaimClose(true))
+		}
+	}
+	command.Check(err)
+
+	remote, err := websockets.NewRemote(rippled)
+	if err != nil {
+		command.Check(fmt.Errorf("failed to connect to %q: %w", rippled, err))
+	}
+
+	var g errgroup.Group
+	var accountInfo *websockets.AccountInfoResult
+	g.Go(func() error {
+		var err error
+		accountInfo, err = remote.AccountInfo(*asAccount)
+		if err != nil {
+			return fmt.Errorf("failed to get account_info (%s): %w", asAccount, err)
+		}
+		return nil
+	})
+	err = g.Wait()
+	command.Check(err)
+
+	options = append([]func(data.Transaction) error{
+		tx.SetAddress(asAccount),
+		tx.SetSourceTag(asTag),
+		tx.SetSequence(*accountInfo.AccountData.Sequence),
+		tx.SetLastLedgerSequence(accountInfo.LedgerSequence + LedgerSequenceInterval),
+		tx.SetFee(txFee()),
+
+		tx.AddMemo(memoFlag),
+		tx.AddMemo(memohex),
+
+		tx.SetCanonicalSig(true),
+	}, options...)
+
+	var t data.Transaction
+	switch subcommand {
+	case "open":
+		t, err = tx.NewPaymentChannelCreate(options...)
+	case "fund":
+		t, err = tx.NewPaymentChannelFund(options...)
+	case "claim", "close":
+		t, err = tx.NewPaymentChannelClaim(options...)
+	}
+	if err != nil {
+		command.Check(fmt.Errorf("failed to prepare channel %s: %w", subcommand, err))
+	}
+
+	// Prepare to encode transaction output.
+	unsignedOut := make(chan (data.Transaction))
+	g.Go(func() error {
+		return pipeline.EncodeOutput(os.Stdout, unsignedOut)
+	})
+
+	// Pass unsigned transaction to encoder
+	unsignedOut <- t
+	close(unsignedOut)
+
+	err = g.Wait()
+	command.Check(err)
+
+	command.V(1).Infof("Prepared unsigned %s by %s.\n", t.GetType(), t.GetBase().Account)
+
+	return nil
+}
+
+// channelOpenOptions parses `<destination> <amount>` and channel
+// parameters.
+func channelOpenOptions(argument []string, settleDelay, cancelAfter, pubkey string) ([]func(data.Transaction) error, error) {
+	destArg, err := cmd.ParseAccountArg(argument[0:1])
+	if err != nil {
+		return nil, fmt.Errorf("bad destination address (%q): %w", argument[0], err)
+	}
+	destTag := &destArg[0].Tag
+	if *destTag == 0 {
+		destTag = nil
+	}
+
+	amount, err := cmd.AmountFromArg(argument[1])
+	if err != nil {
+		return nil, fmt.Errorf("bad amount (%q): %w", argument[1], err)
+	}
+	if !amount.IsNative() {
+		return nil, fmt.Errorf("channel amount must be XRP, got %q", argument[1])
+	}
+
+	delay, err := cmd.ParseDuration(settleDelay)
+	if err != nil {
+		return nil, fmt.Errorf("bad -settle-delay (%q): %w", settleDelay, err)
+	}
+
+	if pubkey == "" {
+		pubkey = asAccount.String()
+	}
+	key, err := cmd.PublicKeyArg(pubkey)
+	if err != nil {
+		return nil, fmt.Errorf("channel public key: %w", err)
+	}
+
+	options := []func(data.Transaction) error{
+		tx.SetDestination(destArg[0].Account),
+		tx.SetDestinationTag(destTag),
+		tx.SetAmount(amount),
+		tx.SetChannelSettleDelay(uint32(delay / time.Second)),
+		tx.SetChannelPublicKey(*key),
+	}
+
+	if cancelAfter != "" {
+		t, err := cmd.ParseTime(cancelAfter)
+		if err != nil {
+			return nil, fmt.Errorf("bad -cancel-after: %w", err)
+		}
+		if !t.After(time.Now()) {
+			return nil, fmt.Errorf("-cancel-after (%s) must be in the future", t)
+		}
+		rippleTime, err := cmd.RippleTime(t)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, tx.SetCancelAfter(rippleTime))
+	}
+	return options, nil
+}
+
+// channelFundOptions parses `<id> <amount>` and optional expiration.
+func channelFundOptions(argument []string, expiration string) ([]func(data.Transaction) error, error) {
+	id, err := data.NewHash256(argument[0])
+	if err != nil {
+		return nil, fmt.Errorf("bad channel ID (%q): %w", argument[0], err)
+	}
+	amount, err := cmd.AmountFromArg(argument[1])
+	if err != nil {
+		return nil, fmt.Errorf("bad amount (%q): %w", argument[1], err)
+	}
+
+	options := []func(data.Transaction) error{
+		tx.SetChannel(*id),
+		tx.SetAmount(amount),
+	}
+	if expiration != "" {
+		t, err := cmd.ParseTime(expiration)
+		if err != nil {
+			return nil, fmt.Errorf("bad -expiration: %w", err)
+		}
+		rippleTime, err := cmd.RippleTime(t)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, tx.SetExpiration(rippleTime))
+	}
+	return options, nil
+}
+
+// channelClaimOptions reads a signed claim, and checks it against the
+// channel on-ledger.
+func channelClaimOptions(rippled string, argument []string, close bool) ([]func(data.Transaction) error, error) {
+	id, err := data.NewHash256(argument[0])
+	if err != nil {
+		return nil, fmt.Errorf("bad channel ID (%q): %w", argument[0], err)
+	}
+
+	var b []byte
+	if len(argument) > 1 {
+		b, err = ioutil.ReadFile(argument[1])
+	} else {
+		b, err = ioutil.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		return nil, err
+	}
+	claim := &util.Claim{}
+	err = json.Unmarshal(b, claim)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse claim: %w", err)
+	}
+	if claim.Channel != *id {
+		return nil, fmt.Errorf("claim is for channel %s, not %s", claim.Channel, id)
+	}
+
+	ws, err := rpc.NewWebsocket(rippled)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %q: %w", rippled, err)
+	}
+	defer ws.Close()
+
+	channel, err := ws.PaymentChannel(*id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get channel %s: %w", id, err)
+	}
+	err = util.VerifyClaim(claim, channel.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	if channel.Destination != *asAccount {
+		command.Infof("channel destination is %s, not %s", cmd.FormatAccount(channel.Destination, nil), cmd.FormatAccount(*asAccount, nil))
+	}
+	if channel.Amount.Less(*claim.Amount.Value) {
+		return nil, fmt.Errorf("claim (%s) exceeds channel amount (%s)", claim.Amount, channel.Amount)
+	}
+	if channel.Balance.Value != nil && !channel.Balance.Less(*claim.Amount.Value) {
+		return nil, fmt.Errorf("claim (%s) does not exceed amount already paid (%s)", claim.Amount, channel.Balance)
+	}
+
+	return []func(data.Transaction) error{
+		tx.SetChannel(*id),
+		tx.SetClaimBalance(claim.Amount),
+		tx.SetClaimAmount(claim.Amount),
+		tx.SetClaimSignature(claim.Signature, claim.PublicKey),
+		tx.SetClaimClose(close),
+	}, nil
+}
@@ -0,0 +1,311 @@
+// Copyright (C) 2020  David N. Cohen
+// This file is part of github.com/dncohen/rcl
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Operation check
+//
+// Compose transactions to create, cash or cancel a check.  A check
+// is like a payment, except the destination decides whether to cash
+// it.
+//
+//   rcl-tx -as treasury check create vendor 100/USD/bitstamp-usd -expires=+30d -invoice=INV-1234
+//   rcl-tx -as vendor check cash <check ID> -amount=100/USD/bitstamp-usd
+//   rcl-tx -as treasury check cancel <check ID>
+//
+// The -invoice may be a 256-bit hex value, or any other text, which
+// is hashed (SHA-256) to produce the InvoiceID.  Cash requires either
+// -amount (exact amount to receive) or -deliver-min (cash as much as
+// possible, but no less).  Use `rcl-account checks` to find check
+// IDs.
+//
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"src.d10.dev/command"
+
+	"github.com/dncohen/rcl/internal/cmd"
+	"github.com/dncohen/rcl/internal/pipeline"
+	"github.com/dncohen/rcl/rpc"
+	"github.com/dncohen/rcl/tx"
+	"github.com/pkg/errors"
+	"github.com/rubblelabs/ripple/data"
+	"github.com/rubblelabs/ripple/websockets"
+)
+
+func init() {
+	command.RegisterOperation(command.Operation{
+		Handler:     opCheck,
+		Name:        "check",
+		Syntax:      "check create <destination> <sendmax> [-expires=<time>] [-invoice=<id>] | check cash <id> -amount=<amount>|-deliver-min=<amount> | check cancel <id>",
+		Description: `Create, cash or cancel a check.`,
+	})
+}
+
+func opCheck() error {
+	expiresFlag := command.OperationFlagSet.String("expires", "", "(create) time after which the check cannot be cashed")
+	invoiceFlag := command.OperationFlagSet.String("invoice", "", "(create) invoice ID, hex or text to be hashed")
+	amountFlag := command.OperationFlagSet.String("amount", "", "(cash) exact amount to receive")
+	deliverMinFlag := command.OperationFlagSet.String("deliver-min", "", "(cash) minimum amount to receive")
+
+	// flags may follow positional args
+	argument, err := cmd.ParseInterspersed(command.OperationFlagSet, command.Args()[1:])
+	command.CheckUsage(err)
+
+	if len(argument) < 1 {
+		command.CheckUsage(errors.New("expected create, cash or cancel"))
+	}
+	subcommand := argument[0]
+	argument = argument[1:]
+
+	switch subcommand {
+	case "create":
+		if len(argument) != 2 {
+			command.CheckUsage(errors.New("check create requires <destination> and <sendmax> arguments"))
+		}
+		if *amountFlag != "" || *deliverMinFlag != "" {
+			command.CheckUsage(errors.New("-amount and -deliver-min apply only to check cash"))
+		}
+	case "cash", "cancel":
+		if len(argument) != 1 {
+			command.CheckUsage(fmt.Errorf("check %s requires <id> argument", subcommand))
+		}
+		if *expiresFlag != "" || *invoiceFlag != "" {
+			command.CheckUsage(errors.New("-expires and -invoice apply only to check create"))
+		}
+		if subcommand == "cash" && (*amountFlag == "") == (*deliverMinFlag == "") {
+			command.CheckUsage(errors.New("check cash requires either -amount or -deliver-min"))
+		}
+		if subcommand == "cancel" && (*amountFlag != "" || *deliverMinFlag != "") {
+			command.CheckUsage(errors.New("-amount and -deliver-min apply only to check cash"))
+		}
+	default:
+		command.CheckUsage(fmt.Errorf("expected create, cash or cancel, got %q", subcommand))
+	}
+
+	// -as <account> is parsed in main.go
+	if asAccount == nil {
+		return errors.New("operation requires -as <account> flag")
+	}
+
+	rippled, err := cmd.Rippled()
+	command.Check(err)
+
+	var options []func(data.Transaction) error
+	switch subcommand {
+	case "create":
+		options, err = checkCreateOptions(argument, *expiresFlag, *invoiceFlag)
+	default:
+		options, err = checkIDOptions(rippled, subcommand, argument[0], *amountFlag, *deliverMinFlag)
+	}
+	command.Check(err)
+
+	remote, err := websockets.NewRemote(rippled)
+	if err != nil {
+		command.Check(fmt.Errorf("failed to connect to %q: %w", rippled, err))
+	}
+
+	var g errgroup.Group
+	var accountInfo *websockets.AccountInfoResult
+	g.Go(func() error {
+		var err error
+		accountInfo, err = remote.AccountInfo(*asAccount)
+		if err != nil {
+			return fmt.Errorf("failed to get account_info (%s): %w", asAccount, err)
+		}
+		return nil
+	})
+	err = g.Wait()
+	command.Check(err)
+
+	options = append([]func(data.Transaction) error{
+		tx.SetAddress(asAccount),
+		tx.SetSourceTag(asTag),
+		tx.SetSequence(*accountInfo.AccountData.Sequence),
+		tx.SetLastLedgerSequence(accountInfo.LedgerSequence + LedgerSequenceInterval),
+		tx.SetFee(txFee()),
+
+		tx.AddMemo(memoFlag),
+		tx.AddMemo(memohex),
+
+		tx.SetCanonicalSig(true),
+	}, options...)
+
+	var t data.Transaction
+	switch subcommand {
+	case "create":
+		t, err = tx.NewCheckCreate(options...)
+	case "cash":
+		t, err = tx.NewCheckCash(options...)
+	case "cancel":
+		t, err = tx.NewCheckCancel(options...)
+	}
+	if err != nil {
+		command.Check(fmt.Errorf("failed to prepare check %s: %w", subcommand, err))
+	}
+
+	// Prepare to encode transaction output.
+	unsignedOut := make(chan (data.Transaction))
+	g.Go(func() error {
+		return pipeline.EncodeOutput(os.Stdout, unsignedOut)
+	})
+
+	// Pass unsigned transaction to encoder
+	unsignedOut <- t
+	close(unsignedOut)
+
+	err = g.Wait()
+	command.Check(err)
+
+	command.V(1).Infof("Prepared unsigned %s by %s.\n", t.GetType(), t.GetBase().Account)
+
+	return nil
+}
+
+// checkCreateOptions parses `<destination> <sendmax>`, expiration and
+// invoice.
+func checkCreateOptions(argument []string, expires, invoice string) ([]func(data.Transaction) error, error) {
+	destArg, err := cmd.ParseAccountArg(argument[0:1])
+	if err != nil {
+		return nil, fmt.Errorf("bad destination address (%q): %w", argument[0], err)
+	}
+	destination := destArg[0].Account
+	destTag := &destArg[0].Tag
+	if *destTag == 0 {
+		destTag = nil
+	}
+	if destination == *asAccount {
+		return nil, errors.New("check destination must differ from sender")
+	}
+
+	sendMax, err := cmd.AmountFromArg(argument[1])
+	if err != nil {
+		return nil, fmt.Errorf("bad sendmax (%q): %w", argument[1], err)
+	}
+	if !sendMax.IsNative() && sendMax.Issuer == zeroAccount {
+		command.V(1).Infof("using %s as %s issuer", asAccount, sendMax.Currency)
+		sendMax.Issuer = *asAccount
+	}
+
+	options := []func(data.Transaction) error{
+		tx.SetDestination(destination),
+		tx.SetDestinationTag(destTag),
+		tx.SetSendMax(sendMax),
+	}
+
+	if expires != "" {
+		t, err := cmd.ParseTime(expires)
+		if err != nil {
+			return nil, fmt.Errorf("bad -expires: %w", err)
+		}
+		if !t.After(time.Now()) {
+			return nil, fmt.Errorf("-expires (%s) must be in the future", t)
+		}
+		expiration, err := cmd.RippleTime(t)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, tx.SetExpiration(expiration))
+	}
+
+	if invoice != "" {
+		options = append(options, tx.SetInvoiceID(invoiceID(invoice)))
+	}
+
+	return options, nil
+}
+
+// invoiceID parses a 256-bit hex invoice ID.  Other text is hashed.
+func invoiceID(invoice string) *data.Hash256 {
+	id, err := data.NewHash256(invoice)
+	if err != nil {
+		// not hex, so hash the text
+		hash := data.Hash256(sha256.Sum256([]byte(invoice)))
+		id = &hash
+		command.V(1).Infof("invoice %q hashed to %s", invoice, id)
+	}
+	return id
+}
+
+// checkIDOptions identifies the check to cash or cancel.  The check
+// is looked up, to catch mistakes before the transaction is signed.
+func checkIDOptions(rippled, subcommand, idArg, amountArg, deliverMinArg string) ([]func(data.Transaction) error, error) {
+	id, err := data.NewHash256(idArg)
+	if err != nil {
+		return nil, fmt.Errorf("bad check ID (%q): %w", idArg, err)
+	}
+
+	ws, err := rpc.NewWebsocket(rippled)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %q: %w", rippled, err)
+	}
+	defer ws.Close()
+
+	checks, err := ws.Checks(*asAccount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get checks of %s: %w", asAccount, err)
+	}
+	var check *rpc.Check
+	for i := range checks {
+		if checks[i].Index == *id {
+			check = &checks[i]
+			break
+		}
+	}
+	if check == nil {
+		return nil, fmt.Errorf("check %s not found among outstanding checks of %s", id, cmd.FormatAccount(*asAccount, nil))
+	}
+	if check.Expiration != nil && subcommand == "cash" {
+		if cmd.FromRippleTime(*check.Expiration).Before(time.Now()) {
+			return nil, fmt.Errorf("check %s expired %s", id, cmd.FormatRippleTime(*check.Expiration))
+		}
+	}
+
+	options := []func(data.Transaction) error{
+		tx.SetCheckID(*id),
+	}
+	if subcommand == "cancel" {
+		return options, nil
+	}
+
+	if check.Destination != *asAccount {
+		return nil, fmt.Errorf("check %s may be cashed only by %s", id, cmd.FormatAccount(check.Destination, nil))
+	}
+
+	arg, setAmount := amountArg, tx.SetAmount
+	if deliverMinArg != "" {
+		arg, setAmount = deliverMinArg, tx.SetDeliverMin
+	}
+	amount, err := cmd.AmountFromArg(arg)
+	if err != nil {
+		return nil, fmt.Errorf("bad amount (%q): %w", arg, err)
+	}
+	if !amount.IsNative() && amount.Issuer == zeroAccount {
+		amount.Issuer = check.SendMax.Issuer
+	}
+	if amount.Asset().String() != check.SendMax.Asset().String() {
+		return nil, fmt.Errorf("amount (%s) must be same currency as check (%s)", amount, check.SendMax)
+	}
+	if check.SendMax.Less(*amount.Value) {
+		return nil, fmt.Errorf("amount (%s) exceeds check (%s)", amount, check.SendMax)
+	}
+
+	return append(options, setAmount(amount)), nil
+}
@@ -0,0 +1,140 @@
+// Copyright (C) 2020  David N. Cohen
+// This file is part of github.com/dncohen/rcl
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Operation combine
+//
+// Combine multi-signatures into a transaction ready to submit.  Input
+// is several copies of a transaction, each signed by `rcl-key sign
+// -multi`.  Output has all the signatures, sorted by signer account
+// as the network requires.
+//
+//   rcl-tx combine alice.json bob.json | rcl-tx submit
+//
+// Copies must be identical, apart from signatures, or combine fails.
+// Input may include more than one transaction (distinguished by
+// account and sequence); each is combined separately.
+//
+// Combine also fails when a transaction fee is too low for its number
+// of signers.  Fee cannot be changed after signing, so compose
+// multi-signed transactions with -multisign=<number of signers>.
+//
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/dncohen/rcl/internal/pipeline"
+	"github.com/dncohen/rcl/util"
+	"github.com/rubblelabs/ripple/data"
+	"src.d10.dev/command"
+)
+
+func init() {
+	command.RegisterOperation(command.Operation{
+		Handler:     opCombine,
+		Name:        "combine",
+		Syntax:      "combine [<filename> ...]",
+		Description: "Combine multi-signed copies of a transaction.  Reads from files, or stdin.",
+	})
+}
+
+// a transaction, and what its signers sign
+type multisigned struct {
+	item pipeline.Item
+	msg  []byte
+}
+
+func opCombine() error {
+	err := command.ParseOperationFlagSet()
+	if err != nil {
+		return err
+	}
+
+	argument := command.OperationFlagSet.Args()
+
+	signedIn := make(chan pipeline.Item)
+	go func() {
+		defer close(signedIn)
+		if len(argument) == 0 {
+			err := pipeline.DecodeInput(signedIn, os.Stdin)
+			command.Check(err)
+		} else {
+			err := pipeline.DecodeFiles(signedIn, argument...)
+			command.Check(err)
+		}
+	}()
+
+	var order []string // preserve order of input
+	combined := make(map[string]*multisigned)
+
+	for item := range signedIn {
+		t := item.Transaction
+		base := t.GetBase()
+		if len(item.Signers) == 0 {
+			return fmt.Errorf("transaction from %s (sequence %d) has no multi-signature", base.Account, base.Sequence)
+		}
+
+		msg, err := util.MultiSigningMessage(t)
+		if err != nil {
+			return err
+		}
+
+		key := fmt.Sprintf("%s-%d", base.Account, base.Sequence)
+		c, ok := combined[key]
+		if !ok {
+			combined[key] = &multisigned{item: item, msg: msg}
+			order = append(order, key)
+			continue
+		}
+		if !bytes.Equal(c.msg, msg) {
+			return fmt.Errorf("copies of transaction from %s (sequence %d) do not match, refusing to combine", base.Account, base.Sequence)
+		}
+		c.item.Signers, err = util.AddSigners(c.item.Transaction, c.item.Signers, item.Signers...)
+		if err != nil {
+			return err
+		}
+		pipeline.MergeApprovals(&c.item, item)
+	}
+
+	if len(order) == 0 {
+		return fmt.Errorf("no transactions to combine")
+	}
+
+	combinedOut := make(chan pipeline.Item)
+	go func() {
+		defer close(combinedOut)
+		for _, key := range order {
+			item := combined[key].item
+			base := item.Transaction.GetBase()
+
+			n := len(item.Signers)
+			min, err := data.NewNativeValue(int64(util.MultiSignFee(n)))
+			command.Check(err)
+			if base.Fee.Less(*min) {
+				command.Check(fmt.Errorf("fee (%s) of transaction from %s (sequence %d) is too low for %d signers, compose with -multisign=%d", base.Fee, base.Account, base.Sequence, n, n))
+			}
+			command.V(1).Infof("combined %d signatures for %s (sequence %d)", n, base.Account, base.Sequence)
+			combinedOut <- item
+		}
+	}()
+
+	err = pipeline.EncodeItems(os.Stdout, combinedOut)
+	command.Check(err)
+
+	return nil
+}
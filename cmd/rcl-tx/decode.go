@@ -0,0 +1,77 @@
+// Copyright (C) 2020  David N. Cohen
+// This file is part of github.com/dncohen/rcl
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Operation decode
+//
+// Decode converts transactions to JSON.  Input is read from files, or
+// stdin, in either pipeline format: JSON, or hex encoded blobs, one
+// per line (as rippled's tx_blob).  For example, to inspect a blob
+// from other XRP Ledger tools:
+//
+//     echo 1200032200000000... | rcl-tx decode
+//
+// See also the encode operation, and -format, which applies to all
+// operations that output transactions.
+//
+package main
+
+import (
+	"os"
+
+	"github.com/dncohen/rcl/internal/pipeline"
+	"src.d10.dev/command"
+)
+
+func init() {
+	command.RegisterOperation(command.Operation{
+		Handler:     opDecode,
+		Name:        "decode",
+		Syntax:      "decode [<filename> ...]",
+		Description: "Convert transactions to JSON.  Reads from files, or stdin.",
+	})
+}
+
+func opDecode() error {
+	return convert(pipeline.FormatJSON)
+}
+
+// convert reads transactions from files named on the command line, or
+// stdin, and writes them in the given format.
+func convert(format string) error {
+	err := command.ParseOperationFlagSet()
+	if err != nil {
+		return err
+	}
+
+	argument := command.OperationFlagSet.Args()
+
+	in := make(chan pipeline.Item)
+	go func() {
+		defer close(in)
+		if len(argument) == 0 {
+			err := pipeline.DecodeInput(in, os.Stdin)
+			command.Check(err)
+		} else {
+			err := pipeline.DecodeFiles(in, argument...)
+			command.Check(err)
+		}
+	}()
+
+	err = pipeline.EncodeFormat(os.Stdout, in, format)
+	command.Check(err)
+
+	return nil
+}
@@ -0,0 +1,47 @@
+// Copyright (C) 2020  David N. Cohen
+// This file is part of github.com/dncohen/rcl
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Operation encode
+//
+// Encode converts transactions to hex encoded blobs, one per line, as
+// rippled's tx_blob.  Input is read from files, or stdin, in either
+// pipeline format.  Use encode to exchange transactions with other XRP
+// Ledger tools, or hardware wallets, which expect blobs.
+//
+//     rcl-tx encode signed.json
+//
+// Note that a blob of a signed transaction is what rippled's submit
+// command expects.  See also the decode operation, and -format.
+//
+package main
+
+import (
+	"github.com/dncohen/rcl/internal/pipeline"
+	"src.d10.dev/command"
+)
+
+func init() {
+	command.RegisterOperation(command.Operation{
+		Handler:     opEncode,
+		Name:        "encode",
+		Syntax:      "encode [<filename> ...]",
+		Description: "Convert transactions to hex blobs.  Reads from files, or stdin.",
+	})
+}
+
+func opEncode() error {
+	return convert(pipeline.FormatBlob)
+}
@@ -0,0 +1,415 @@
+// Copyright (C) 2020  David N. Cohen
+// This file is part of github.com/dncohen/rcl
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Operation escrow
+//
+// Compose transactions to create, finish or cancel an escrow of XRP.
+//
+//   rcl-tx -as treasury escrow create hot 1000/XRP -finish-after=2021-01-01 -cancel-after=+90d
+//   rcl-tx -as hot escrow finish treasury 42
+//   rcl-tx -as treasury escrow cancel <hash of EscrowCreate tx>
+//
+// Times may be a date ("2021-01-01"), date and time ("2021-01-01
+// 17:00", local time unless RFC3339 with zone), or relative to now
+// ("+36h", "+30d").  Finish and cancel identify the escrow by owner
+// and sequence of the EscrowCreate transaction, or by that
+// transaction's hash, in which case owner and sequence are looked up.
+//
+// With -condition, create generates a random PREIMAGE-SHA-256
+// crypto-condition.  The escrow can be finished only by a transaction
+// including the fulfillment (the preimage).  The fulfillment is saved
+// to a file named '[OWNER]-[SEQUENCE].rcl-fulfillment', in the
+// current directory, readable only by its owner.  Like an `.rcl-key`
+// file, it must be kept secret until the escrow is to be finished.
+// Use `-condition=<hex>` to create an escrow with an existing
+// condition, i.e. one provided by a counterparty.
+//
+//   rcl-tx -as treasury escrow create hot 1000/XRP -condition -cancel-after=+7d
+//   rcl-tx -as hot escrow finish treasury 43 -fulfillment
+//
+// Finish with -fulfillment reads the file saved by create, or use
+// `-fulfillment=<file>` or `-fulfillment=<hex>`.  The fee of finish
+// increases with the size of the fulfillment.
+//
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"src.d10.dev/command"
+
+	"github.com/dncohen/rcl/cryptocondition"
+	"github.com/dncohen/rcl/internal/cmd"
+	"github.com/dncohen/rcl/internal/keyfile"
+	"github.com/dncohen/rcl/internal/pipeline"
+	"github.com/dncohen/rcl/tx"
+	"github.com/dncohen/rcl/util"
+	"github.com/pkg/errors"
+	"github.com/rubblelabs/ripple/data"
+	"github.com/rubblelabs/ripple/websockets"
+)
+
+// optionalArg is a flag which may be given with or without a value,
+// i.e. `-condition` or `-condition=<hex>`.
+type optionalArg struct {
+	set   bool
+	value string
+}
+
+func (o *optionalArg) String() string {
+	if o == nil {
+		return ""
+	}
+	return o.value
+}
+
+func (o *optionalArg) Set(s string) error {
+	o.set = s != "false"
+	if s != "true" && s != "false" {
+		o.value = s
+	}
+	return nil
+}
+
+func (o *optionalArg) IsBoolFlag() bool { return true }
+
+func init() {
+	command.RegisterOperation(command.Operation{
+		Handler:     opEscrow,
+		Name:        "escrow",
+		Syntax:      "escrow create <destination> <amount> [-finish-after=<time>] [-cancel-after=<time>] [-condition[=<hex>]] | escrow finish|cancel <owner> <sequence> | escrow finish|cancel <tx hash> [-fulfillment[=<file or hex>]]",
+		Description: `Create, finish or cancel an escrow of XRP.`,
+	})
+}
+
+func opEscrow() error {
+	finishAfterFlag := command.OperationFlagSet.String("finish-after", "", "(create) time after which escrow may be finished")
+	cancelAfterFlag := command.OperationFlagSet.String("cancel-after", "", "(create) time after which escrow may be cancelled")
+	conditionFlag := &optionalArg{}
+	command.OperationFlagSet.Var(conditionFlag, "condition", "(create) generate a crypto-condition, or use the hex encoded condition given")
+	fulfillmentFlag := &optionalArg{}
+	command.OperationFlagSet.Var(fulfillmentFlag, "fulfillment", "(finish) fulfill the escrow's condition, from the file saved by create, or the file or hex given")
+
+	// flags may follow positional args, i.e. `escrow create <dest> <amount> -finish-after=...`
+	argument, err := cmd.ParseInterspersed(command.OperationFlagSet, command.Args()[1:])
+	command.CheckUsage(err)
+
+	if len(argument) < 1 {
+		command.CheckUsage(errors.New("expected create, finish or cancel"))
+	}
+	subcommand := argument[0]
+	argument = argument[1:]
+
+	switch subcommand {
+	case "create":
+		if fulfillmentFlag.set {
+			command.CheckUsage(errors.New("-fulfillment applies only to escrow finish"))
+		}
+	case "finish", "cancel":
+		if *finishAfterFlag != "" || *cancelAfterFlag != "" || conditionFlag.set {
+			command.CheckUsage(fmt.Errorf("-finish-after, -cancel-after and -condition apply only to escrow create, not %s", subcommand))
+		}
+		if subcommand == "cancel" && fulfillmentFlag.set {
+			command.CheckUsage(errors.New("-fulfillment applies only to escrow finish"))
+		}
+	default:
+		command.CheckUsage(fmt.Errorf("expected create, finish or cancel, got %q", subcommand))
+	}
+
+	// -as <account> is parsed in main.go
+	if asAccount == nil {
+		return errors.New("operation requires -as <account> flag")
+	}
+
+	rippled, err := cmd.Rippled()
+	command.Check(err)
+
+	remote, err := websockets.NewRemote(rippled)
+	if err != nil {
+		command.Check(fmt.Errorf("failed to connect to %q: %w", rippled, err))
+	}
+
+	fee := txFee()
+	var options []func(data.Transaction) error
+	var generated *keyfile.Fulfillment // new condition, to be saved
+	if subcommand == "create" {
+		options, err = escrowCreateOptions(argument, *finishAfterFlag, *cancelAfterFlag, conditionFlag.set)
+		command.CheckUsage(err)
+
+		if conditionFlag.set {
+			var condition []byte
+			if conditionFlag.value == "" {
+				preimage, err := cryptocondition.NewPreimage()
+				command.Check(err)
+				condition = preimage.Condition()
+				generated = &keyfile.Fulfillment{
+					Condition:   condition,
+					Fulfillment: preimage.Fulfillment(),
+				}
+			} else {
+				condition, err = hex.DecodeString(conditionFlag.value)
+				if err != nil {
+					command.CheckUsage(fmt.Errorf("bad -condition (%q), expected hex: %w", conditionFlag.value, err))
+				}
+			}
+			options = append(options, tx.SetCondition(condition))
+		}
+	} else {
+		owner, sequence, err := escrowArg(remote, argument)
+		command.CheckUsage(err)
+		options = append(options,
+			tx.SetOwner(owner),
+			tx.SetOfferSequence(sequence),
+		)
+
+		if fulfillmentFlag.set {
+			fulfillment, err := escrowFulfillment(fulfillmentFlag.value, owner, sequence)
+			command.Check(err)
+			preimage, err := cryptocondition.ParseFulfillment(fulfillment)
+			command.Check(err)
+			options = append(options,
+				tx.SetCondition(preimage.Condition()),
+				tx.SetFulfillment(fulfillment),
+			)
+			fee = escrowFinishFee(len(fulfillment))
+		}
+	}
+
+	var g errgroup.Group
+	var accountInfo *websockets.AccountInfoResult
+	g.Go(func() error {
+		var err error
+		accountInfo, err = remote.AccountInfo(*asAccount)
+		if err != nil {
+			return fmt.Errorf("failed to get account_info (%s): %w", asAccount, err)
+		}
+		return nil
+	})
+	err = g.Wait()
+	command.Check(err)
+
+	options = append([]func(data.Transaction) error{
+		tx.SetAddress(asAccount),
+		tx.SetSourceTag(asTag),
+		tx.SetSequence(*accountInfo.AccountData.Sequence),
+		tx.SetLastLedgerSequence(accountInfo.LedgerSequence + LedgerSequenceInterval),
+		tx.SetFee(fee),
+
+		tx.AddMemo(memoFlag),
+		tx.AddMemo(memohex),
+
+		tx.SetCanonicalSig(true),
+	}, options...)
+
+	var t data.Transaction
+	switch subcommand {
+	case "create":
+		t, err = tx.NewEscrowCreate(options...)
+	case "finish":
+		t, err = tx.NewEscrowFinish(options...)
+	case "cancel":
+		t, err = tx.NewEscrowCancel(options...)
+	}
+	if err != nil {
+		command.Check(fmt.Errorf("failed to prepare escrow %s: %w", subcommand, err))
+	}
+
+	if generated != nil {
+		// save the fulfillment before the transaction can be submitted
+		generated.Owner = *asAccount
+		generated.Sequence = *accountInfo.AccountData.Sequence
+		filename := keyfile.FulfillmentFilename(generated.Owner, generated.Sequence)
+		err = keyfile.SaveFulfillment(generated, filename)
+		if err != nil {
+			command.Check(fmt.Errorf("failed to save fulfillment: %w", err))
+		}
+		command.Infof("Saved escrow fulfillment: %s", filename)
+	}
+
+	// Prepare to encode transaction output.
+	unsignedOut := make(chan (data.Transaction))
+	g.Go(func() error {
+		return pipeline.EncodeOutput(os.Stdout, unsignedOut)
+	})
+
+	// Pass unsigned transaction to encoder
+	unsignedOut <- t
+	close(unsignedOut)
+
+	err = g.Wait()
+	command.Check(err)
+
+	command.V(1).Infof("Prepared unsigned %s by %s.\n", t.GetType(), t.GetBase().Account)
+
+	return nil
+}
+
+// escrowFinishFee is the fee of EscrowFinish with a fulfillment, 33
+// fee units plus one per 16 bytes of fulfillment, in place of the
+// usual one unit.
+func escrowFinishFee(fulfillmentSize int) int {
+	return checkFee(feeUnit() * util.FeeUnits(*multisignFlag, fulfillmentSize))
+}
+
+// escrowFulfillment reads the fulfillment given by -fulfillment,
+// either a hex value or file.  When no value is given, the file saved
+// by `escrow create -condition` is read.
+func escrowFulfillment(arg string, owner data.Account, sequence uint32) ([]byte, error) {
+	if arg == "" {
+		arg = keyfile.FulfillmentFilename(owner, sequence)
+	} else if _, err := os.Stat(arg); err != nil {
+		b, err := hex.DecodeString(arg)
+		if err != nil {
+			return nil, fmt.Errorf("bad -fulfillment (%q), expected file or hex", arg)
+		}
+		return b, nil
+	}
+
+	f, err := keyfile.ReadFulfillment(arg)
+	if err != nil {
+		return nil, err
+	}
+	if f.Owner != owner || f.Sequence != sequence {
+		return nil, fmt.Errorf("fulfillment %q is for escrow %s %d, not %s %d", arg, f.Owner, f.Sequence, owner, sequence)
+	}
+	err = cryptocondition.Verify(f.Condition, f.Fulfillment)
+	if err != nil {
+		return nil, fmt.Errorf("fulfillment %q: %w", arg, err)
+	}
+	return f.Fulfillment, nil
+}
+
+// escrowCreateOptions parses `<destination> <amount>` and escrow
+// times.  Condition, if any, is added by caller.
+func escrowCreateOptions(argument []string, finishAfter, cancelAfter string, condition bool) ([]func(data.Transaction) error, error) {
+	if len(argument) != 2 {
+		return nil, errors.New("escrow create requires <destination> and <amount> arguments")
+	}
+	if finishAfter == "" && !condition {
+		return nil, errors.New("escrow create requires -finish-after or -condition (or both)")
+	}
+
+	destArg, err := cmd.ParseAccountArg(argument[0:1])
+	if err != nil {
+		return nil, fmt.Errorf("bad destination address (%q): %w", argument[0], err)
+	}
+	destTag := &destArg[0].Tag
+	if *destTag == 0 {
+		destTag = nil
+	}
+
+	amount, err := cmd.AmountFromArg(argument[1])
+	if err != nil {
+		return nil, fmt.Errorf("bad amount (%q): %w", argument[1], err)
+	}
+	if !amount.IsNative() {
+		return nil, fmt.Errorf("escrow amount must be XRP, got %q", argument[1])
+	}
+
+	options := []func(data.Transaction) error{
+		tx.SetAmount(amount),
+		tx.SetDestination(destArg[0].Account),
+		tx.SetDestinationTag(destTag),
+	}
+
+	now := time.Now()
+	var finish, cancel time.Time
+	if finishAfter != "" {
+		finish, err = cmd.ParseTime(finishAfter)
+		if err != nil {
+			return nil, fmt.Errorf("bad -finish-after: %w", err)
+		}
+		if !finish.After(now) {
+			return nil, fmt.Errorf("-finish-after (%s) must be in the future", finish)
+		}
+		rippleTime, err := cmd.RippleTime(finish)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, tx.SetFinishAfter(rippleTime))
+	}
+	if cancelAfter != "" {
+		cancel, err = cmd.ParseTime(cancelAfter)
+		if err != nil {
+			return nil, fmt.Errorf("bad -cancel-after: %w", err)
+		}
+		if !cancel.After(now) {
+			return nil, fmt.Errorf("-cancel-after (%s) must be in the future", cancel)
+		}
+		if finishAfter != "" && !cancel.After(finish) {
+			return nil, fmt.Errorf("-cancel-after (%s) must be later than -finish-after (%s)", cancel, finish)
+		}
+		rippleTime, err := cmd.RippleTime(cancel)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, tx.SetCancelAfter(rippleTime))
+	}
+
+	return options, nil
+}
+
+// escrowArg identifies an escrow, either by `<owner> <sequence>` or
+// by the hash of the EscrowCreate transaction.
+func escrowArg(remote *websockets.Remote, argument []string) (owner data.Account, sequence uint32, err error) {
+	switch len(argument) {
+	case 1:
+		var hash data.Hash256
+		b, err := hex.DecodeString(argument[0])
+		if err != nil || len(b) != len(hash) {
+			return owner, sequence, fmt.Errorf("expected hash of EscrowCreate transaction, got %q", argument[0])
+		}
+		copy(hash[:], b)
+
+		result, err := remote.Tx(hash)
+		if err != nil {
+			return owner, sequence, fmt.Errorf("failed to get transaction %s: %w", hash, err)
+		}
+		create, ok := result.Transaction.(*data.EscrowCreate)
+		if !ok {
+			return owner, sequence, fmt.Errorf("expected EscrowCreate transaction, %s is %s", hash, result.Transaction.GetType())
+		}
+		if !result.Validated {
+			command.Infof("EscrowCreate transaction %s is not (yet) validated", hash)
+		}
+		owner = create.Account
+		sequence = create.Sequence
+		command.V(1).Infof("escrow created by %s, sequence %d", cmd.FormatAccount(owner, nil), sequence)
+
+	case 2:
+		ownerArg, err := cmd.ParseAccountArg(argument[0:1])
+		if err != nil {
+			return owner, sequence, fmt.Errorf("bad owner address (%q): %w", argument[0], err)
+		}
+		owner = ownerArg[0].Account
+
+		seq, err := strconv.ParseUint(argument[1], 10, 32)
+		if err != nil || seq < 1 {
+			return owner, sequence, fmt.Errorf("expected sequence of EscrowCreate transaction, got %q", argument[1])
+		}
+		sequence = uint32(seq)
+
+	default:
+		return owner, sequence, errors.New("expected <owner> <sequence>, or hash of EscrowCreate transaction")
+	}
+
+	return owner, sequence, nil
+}
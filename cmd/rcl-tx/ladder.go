@@ -0,0 +1,278 @@
+// Copyright (C) 2020  David N. Cohen
+// This file is part of github.com/dncohen/rcl
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Operation ladder
+//
+// Ladder composes a series of offers, selling an asset across a range
+// of prices, for market making.  For example, to sell 1000 XRP in 5
+// offers priced from 0.50 to 0.60 USD:
+//
+//     rcl-tx -as desk ladder XRP USD/bitstamp -from-price=0.50 -to-price=0.60 -steps=5 -total=1000
+//
+// Each offer sells an equal part of -total (in units of the asset
+// sold).  Prices are per unit sold, in the asset bought, spaced
+// linearly or, with -geometric, by a constant ratio.  An asset other
+// than XRP must name its issuer, by address or nickname.
+//
+// With -cancel, the account's existing offers selling the same asset
+// for the same asset bought are cancelled first.  Transactions have
+// consecutive sequence numbers, so may be signed and submitted as a
+// batch:
+//
+//     rcl-tx -as desk ladder ... -cancel | rcl-key sign | rcl-tx submit
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+	"src.d10.dev/command"
+
+	"github.com/dncohen/rcl/internal/cmd"
+	"github.com/dncohen/rcl/internal/pipeline"
+	"github.com/dncohen/rcl/tx"
+	"github.com/pkg/errors"
+	"github.com/rubblelabs/ripple/data"
+	"github.com/rubblelabs/ripple/websockets"
+)
+
+func init() {
+	command.RegisterOperation(command.Operation{
+		Handler:     opLadder,
+		Name:        "ladder",
+		Syntax:      "ladder <sell-asset> <buy-asset> -from-price=<price> -to-price=<price> -steps=<int> -total=<amount> [-geometric] [-cancel] [-passive] [-expires=<time>]",
+		Description: `Compose a series of offers across a range of prices.`,
+	})
+}
+
+func opLadder() error {
+	fromFlag := command.OperationFlagSet.String("from-price", "", "price of first offer, per unit sold")
+	toFlag := command.OperationFlagSet.String("to-price", "", "price of last offer, per unit sold")
+	stepsFlag := command.OperationFlagSet.Int("steps", 0, "number of offers")
+	totalFlag := command.OperationFlagSet.String("total", "", "total to sell, in units of sell-asset, across all offers")
+	geometricFlag := command.OperationFlagSet.Bool("geometric", false, "space prices by constant ratio, rather than constant difference")
+	cancelFlag := command.OperationFlagSet.Bool("cancel", false, "first cancel existing offers in the same order book")
+	passiveFlag := command.OperationFlagSet.Bool("passive", false, "do not consume offers exactly matching these")
+	expiresFlag := command.OperationFlagSet.String("expires", "", "time offers expire, i.e. \"+1h\"")
+
+	argument, err := cmd.ParseInterspersed(command.OperationFlagSet, command.Args()[1:])
+	command.CheckUsage(err)
+	if len(argument) != 2 {
+		command.CheckUsage(errors.New("expected arguments: <sell-asset> <buy-asset>"))
+	}
+
+	// -as <account> is parsed in main.go
+	if asAccount == nil {
+		command.CheckUsage(errors.New("operation requires -as <account> flag"))
+	}
+
+	sellAsset, err := assetArg(argument[0])
+	if err != nil {
+		command.Check(fmt.Errorf("bad sell-asset (%q): %w", argument[0], err))
+	}
+	buyAsset, err := assetArg(argument[1])
+	if err != nil {
+		command.Check(fmt.Errorf("bad buy-asset (%q): %w", argument[1], err))
+	}
+	if sameAsset(*sellAsset, *buyAsset) {
+		command.Check(errors.New("sell-asset and buy-asset must differ"))
+	}
+
+	if *stepsFlag < 1 {
+		command.CheckUsage(errors.New("-steps must be at least 1"))
+	}
+	prices, err := ladderPrices(*fromFlag, *toFlag, *stepsFlag, *geometricFlag)
+	if err != nil {
+		command.CheckUsage(err)
+	}
+
+	total, ok := new(big.Rat).SetString(*totalFlag)
+	if !ok || total.Sign() <= 0 {
+		command.CheckUsage(fmt.Errorf("bad -total (%q), expected positive number", *totalFlag))
+	}
+	quantity := formatDecimal(new(big.Rat).Quo(total, big.NewRat(int64(*stepsFlag), 1)), isXRP(argument[0]))
+
+	flags := data.TxSell
+	if *passiveFlag {
+		flags |= data.TxPassive
+	}
+	options := []func(data.Transaction) error{
+		tx.SetFlags(flags),
+	}
+	if *expiresFlag != "" {
+		t, err := cmd.ParseTime(*expiresFlag)
+		if err == nil && !t.After(time.Now()) {
+			err = fmt.Errorf("%s is not in the future", t)
+		}
+		var expiration uint32
+		if err == nil {
+			expiration, err = cmd.RippleTime(t)
+		}
+		if err != nil {
+			command.Check(fmt.Errorf("bad -expires (%q): %w", *expiresFlag, err))
+		}
+		options = append(options, tx.SetExpiration(expiration))
+	}
+
+	// Compose each offer's amounts before connecting, to catch errors early.
+	type step struct {
+		takerGets, takerPays *data.Amount
+	}
+	var steps []step
+	for _, price := range prices {
+		takerGets, err := cmd.AmountFromArg(quantity + "/" + argument[0])
+		if err != nil {
+			command.Check(fmt.Errorf("bad amount to sell (%s/%s): %w", quantity, argument[0], err))
+		}
+		takerPays, err := priceAmount(quantity, price, argument[1])
+		if err != nil {
+			command.Check(fmt.Errorf("bad amount at price %s: %w", price, err))
+		}
+		steps = append(steps, step{takerGets, takerPays})
+	}
+
+	rippled, err := cmd.Rippled()
+	command.Check(err)
+
+	remote, err := websockets.NewRemote(rippled)
+	if err != nil {
+		command.Check(fmt.Errorf("failed to connect to %q: %w", rippled, err))
+	}
+	defer remote.Close()
+
+	var g errgroup.Group
+	var accountInfo *websockets.AccountInfoResult
+	var cancels []uint32
+	g.Go(func() error {
+		var err error
+		accountInfo, err = remote.AccountInfo(*asAccount)
+		if err != nil {
+			return fmt.Errorf("failed to get account_info %s: %w", asAccount, err)
+		}
+		return nil
+	})
+	if *cancelFlag {
+		g.Go(func() error {
+			var err error
+			cancels, err = offerSequences(remote, *asAccount, func(offer data.AccountOffer) bool {
+				return sameAsset(offer.TakerGets, *sellAsset) && sameAsset(offer.TakerPays, *buyAsset)
+			})
+			return err
+		})
+	}
+	err = g.Wait()
+	command.Check(err)
+
+	// Prepare to encode transaction output.
+	unsignedOut := make(chan (data.Transaction))
+	g.Go(func() error {
+		return pipeline.EncodeOutput(os.Stdout, unsignedOut)
+	})
+
+	sequence := *accountInfo.AccountData.Sequence
+	for _, offerSeq := range cancels {
+		t, err := newOfferCancel(sequence, accountInfo.LedgerSequence, offerSeq)
+		command.Check(err)
+		unsignedOut <- t
+		sequence++
+	}
+	for i, step := range steps {
+		offer, err := tx.NewOfferCreate(append([]func(data.Transaction) error{
+			tx.SetAddress(asAccount),
+			tx.SetSourceTag(asTag),
+			tx.SetSequence(sequence),
+			tx.SetLastLedgerSequence(accountInfo.LedgerSequence + LedgerSequenceInterval),
+			tx.SetFee(txFee()),
+
+			tx.AddMemo(memoFlag),
+			tx.AddMemo(memohex),
+
+			tx.SetTakerGets(step.takerGets),
+			tx.SetTakerPays(step.takerPays),
+
+			tx.SetCanonicalSig(true),
+		}, options...)...)
+		if err != nil {
+			command.Check(fmt.Errorf("failed to prepare offer: %w", err))
+		}
+		command.V(1).Infof("offer %d of %d: sell %s for %s (price %s)", i+1, len(steps), step.takerGets, step.takerPays, prices[i])
+		unsignedOut <- offer
+		sequence++
+	}
+	close(unsignedOut)
+
+	err = g.Wait()
+	command.Check(err)
+
+	command.Infof("Prepared %d OfferCancel and %d OfferCreate by %s.", len(cancels), len(steps), asAccount)
+
+	return nil
+}
+
+// ladderPrices returns steps prices, from first to last inclusive,
+// formatted for priceAmount.
+func ladderPrices(fromArg, toArg string, steps int, geometric bool) ([]string, error) {
+	from, ok := new(big.Rat).SetString(fromArg)
+	if !ok || from.Sign() <= 0 {
+		return nil, fmt.Errorf("bad -from-price (%q), expected positive number", fromArg)
+	}
+	if steps == 1 {
+		return []string{fromArg}, nil
+	}
+	to, ok := new(big.Rat).SetString(toArg)
+	if !ok || to.Sign() <= 0 {
+		return nil, fmt.Errorf("bad -to-price (%q), expected positive number", toArg)
+	}
+
+	var prices []string
+	for i := 0; i < steps; i++ {
+		fraction := big.NewRat(int64(i), int64(steps-1))
+		if geometric {
+			f, _ := from.Float64()
+			t, _ := to.Float64()
+			x, _ := fraction.Float64()
+			prices = append(prices, strconv.FormatFloat(f*math.Pow(t/f, x), 'g', 10, 64))
+		} else {
+			// from + (to-from)*fraction, exactly
+			price := new(big.Rat).Sub(to, from)
+			price.Mul(price, fraction)
+			price.Add(price, from)
+			prices = append(prices, formatDecimal(price, false))
+		}
+	}
+	return prices, nil
+}
+
+// assetArg parses an asset, i.e. "XRP" or "USD/bitstamp", as an
+// amount of one unit.
+func assetArg(arg string) (*data.Amount, error) {
+	if !isXRP(arg) && len(strings.Split(arg, "/")) != 2 {
+		return nil, errors.New("expected XRP or <currency>/<issuer>")
+	}
+	return cmd.AmountFromArg("1/" + arg)
+}
+
+// sameAsset returns true when amounts have the same currency and
+// issuer.
+func sameAsset(a, b data.Amount) bool {
+	return a.Currency == b.Currency && a.Issuer == b.Issuer
+}
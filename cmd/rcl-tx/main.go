@@ -32,6 +32,26 @@
 // Each subcommand has its own set of flags, which if used must appear
 // after the subcommand name.
 //
+// Transaction fees are based on current network load, by default
+// (-fee=auto).  Use -fee=min for the lowest fee the network accepts
+// (the transaction may be queued), or -fee=<drops> for a fixed number
+// of drops per fee unit.  Most transactions cost one fee unit;
+// multi-signed transactions (see -multisign) and escrows finished with
+// a fulfillment cost more.  A fee above `maxfee=<drops>` (default
+// 10000) in configuration is an error, rather than composed.
+//
+// Transactions are written in JSON format, by default.  With
+// -format=blob, each is written as a line of hex, as rippled's
+// tx_blob.  Operations which read transactions accept either format.
+//
+// With -envelope, or -describe=<text>, composed transactions are
+// written in an envelope noting who composed them (`composer=<name>`
+// in configuration, by default user@host), when, for which network
+// (`network=<name>`, by default the rippled address), the description
+// and the nicknames of accounts involved.  This helps those who review
+// transactions before signing.  Operations which pass transactions
+// along, i.e. `rcl-key sign`, preserve envelopes.
+//
 // For a list of available subcommands and global flags, run
 //
 //     rcl-tx -help
@@ -48,8 +68,12 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/dncohen/rcl/internal/cmd"
+	"github.com/dncohen/rcl/internal/pipeline"
+	"github.com/dncohen/rcl/rpc"
+	"github.com/dncohen/rcl/util"
 	"github.com/pkg/errors"
 	"github.com/rubblelabs/ripple/data"
 	"src.d10.dev/command"
@@ -66,6 +90,19 @@ var (
 	memoFlag    *string
 	memohexFlag *string
 	memohex     []byte
+
+	// number of signers, when composing multi-signed transactions
+	multisignFlag *int
+
+	// fee policy, "auto", "min" or drops per fee unit
+	feeFlag *string
+
+	// pipeline output, "json" or "blob"
+	formatFlag *string
+
+	// provenance of composed transactions
+	envelopeFlag *bool
+	describeFlag *string
 )
 
 const (
@@ -100,6 +137,12 @@ func main() {
 	memoFlag = command.CommandFlagSet.String("memo", "", "note, to be hex encoded and written to ledger with a transaction")
 	memohexFlag = command.CommandFlagSet.String("memohex", "", "note, already hex encoded")
 
+	multisignFlag = command.CommandFlagSet.Int("multisign", 0, "number of signers, when transaction will be multi-signed (increases fee)")
+	feeFlag = command.CommandFlagSet.String("fee", util.FeeAuto, fmt.Sprintf("transaction fee, %q (based on network load), %q, or drops per fee unit", util.FeeAuto, util.FeeMin))
+	formatFlag = command.CommandFlagSet.String("format", pipeline.FormatJSON, fmt.Sprintf("format of output transactions, %q or %q", pipeline.FormatJSON, pipeline.FormatBlob))
+	envelopeFlag = command.CommandFlagSet.Bool("envelope", false, "write composed transactions in an envelope, noting composer, time and network (implied by -describe)")
+	describeFlag = command.CommandFlagSet.String("describe", "", "description of composed transactions, for those who review them")
+
 	// note, command.Config() calls command.CommandFlagSet.Parse()
 	_, err := command.Config()
 	if errors.Cause(err) == config.ConfigNotFound {
@@ -120,6 +163,15 @@ func main() {
 		asAccount, asTag = &tmp[0].Account, &tmp[0].Tag
 	}
 
+	pipeline.OutputFormat, err = pipeline.ParseFormat(*formatFlag)
+	command.CheckUsage(err)
+	if *envelopeFlag || *describeFlag != "" {
+		if pipeline.OutputFormat == pipeline.FormatBlob {
+			command.CheckUsage(errors.New("envelope cannot be written in blob format"))
+		}
+		pipeline.NewEnvelope = newEnvelope
+	}
+
 	if *memohexFlag != "" {
 		memohex = make([]byte, hex.DecodedLen(len([]byte(*memohexFlag))))
 		_, err := hex.Decode(memohex, []byte(*memohexFlag))
@@ -143,6 +195,73 @@ func main() {
 
 }
 
+// Drops per fee unit, learned once per run.
+var feeUnitDrops int
+
+// feeUnit returns the fee, in drops, of one fee unit, according to
+// -fee.  Unless -fee specifies drops, rippled is queried for current
+// network load.
+func feeUnit() int {
+	if feeUnitDrops > 0 {
+		return feeUnitDrops
+	}
+	var source util.FeeSource
+	if *feeFlag == util.FeeAuto || *feeFlag == util.FeeMin {
+		rippled, err := cmd.Rippled()
+		command.Check(err)
+		ws, err := rpc.NewWebsocket(rippled)
+		command.Check(err)
+		defer ws.Close()
+		source = ws
+	}
+	drops, err := util.FeePerUnit(*feeFlag, source)
+	command.Check(err)
+	command.V(1).Infof("fee %d drops per unit (-fee=%s)", drops, *feeFlag)
+	feeUnitDrops = drops
+	return feeUnitDrops
+}
+
+// txFee returns the fee, in drops, of composed transactions.  A
+// multi-signed transaction pays more, depending on number of signers.
+func txFee() int {
+	return checkFee(feeUnit() * util.FeeUnits(*multisignFlag, 0))
+}
+
+// checkFee fails when fee exceeds the `maxfee` configured.
+func checkFee(fee int) int {
+	max, err := cmd.MaxFee()
+	command.Check(err)
+	if fee > max {
+		command.Check(fmt.Errorf("fee (%d drops) exceeds maxfee (%d drops); use -fee or raise maxfee in configuration", fee, max))
+	}
+	return fee
+}
+
+// newEnvelope notes the provenance of a composed transaction.
+func newEnvelope(t data.Transaction) *pipeline.Envelope {
+	composer, err := cmd.Composer()
+	command.Check(err)
+	network, err := cmd.Network()
+	command.Check(err)
+	now := time.Now().UTC()
+
+	nicknames := make(map[string]string)
+	for _, account := range pipeline.Accounts(t) {
+		nick := cmd.FormatAccount(account, nil)
+		if nick != account.String() {
+			nicknames[account.String()] = nick
+		}
+	}
+
+	return &pipeline.Envelope{
+		Composer:    composer,
+		Composed:    &now,
+		Network:     network,
+		Description: *describeFlag,
+		Nicknames:   nicknames,
+	}
+}
+
 // Encode a transaction to JSON.  A helper function for debug output
 // and saving to file.  Note that when in pipeline, transactions
 // should be encoded and decode by the util/marshal helper package.
@@ -0,0 +1,214 @@
+// Copyright (C) 2020  David N. Cohen
+// This file is part of github.com/dncohen/rcl
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Operation quote
+//
+// Quote shows ways, found by pathfinding, to deliver an amount to a
+// destination, and what each would cost the -as account.
+//
+//     rcl-tx -as treasury quote supplier 100/EUR/bitstamp -from=USD,XRP
+//
+// Each alternative is shown with its source amount and effective rate
+// (source spent per unit delivered).  Without -from, rippled considers
+// all currencies the account holds.  A currency may name an issuer, by
+// address or nickname, i.e. `-from=USD/bitstamp`.
+//
+// Amounts are estimates, against the current open ledger.  The send
+// operation uses the same pathfinding, see `rcl-tx send -help`.
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"src.d10.dev/command"
+
+	"github.com/dncohen/rcl/internal/cmd"
+	"github.com/dncohen/rcl/rpc"
+	"github.com/dncohen/rcl/util"
+	"github.com/pkg/errors"
+	"github.com/rubblelabs/ripple/data"
+)
+
+func init() {
+	command.RegisterOperation(command.Operation{
+		Handler:     opQuote,
+		Name:        "quote",
+		Syntax:      "quote <destination> <amount> [-from=<currency>[/<issuer>],...]",
+		Description: `Show pathfinding alternatives, with effective rates, for a cross-currency payment.`,
+	})
+}
+
+func opQuote() error {
+	fromFlag := command.OperationFlagSet.String("from", "", "currencies to spend, comma separated (default any held by -as account)")
+
+	argument, err := cmd.ParseInterspersed(command.OperationFlagSet, command.Args()[1:])
+	command.CheckUsage(err)
+	if len(argument) != 2 {
+		command.CheckUsage(errors.New("operation requires <destination> and <amount> arguments"))
+	}
+
+	// -as <account> is parsed in main.go
+	if asAccount == nil {
+		command.CheckUsage(errors.New("operation requires -as <account> flag"))
+	}
+
+	destinationArg, err := cmd.ParseAccountArg(argument[0:1])
+	if err != nil {
+		command.Check(fmt.Errorf("bad destination address (%q): %w", argument[0], err))
+	}
+	destination := destinationArg[0].Account
+
+	amount, err := cmd.AmountFromArg(argument[1])
+	if err != nil {
+		command.Check(fmt.Errorf("bad amount (%q): %w", argument[1], err))
+	}
+	if !amount.IsNative() && amount.Issuer == zeroAccount {
+		amount.Issuer = destination
+	}
+
+	currencies, err := parseCurrencies(*fromFlag)
+	command.Check(err)
+
+	rippled, err := cmd.Rippled()
+	command.Check(err)
+	ws, err := rpc.NewWebsocket(rippled)
+	command.Check(err)
+	defer ws.Close()
+
+	alternatives, err := findPaths(ws, *asAccount, destination, *amount, currencies)
+	command.Check(err)
+
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', tabwriter.Debug)
+	fmt.Fprintf(table, "Deliver\t Source Amount\t Rate\t Paths\t\n")
+	for _, alt := range alternatives {
+		rate := alt.Source_amount.Ratio(*amount)
+		fmt.Fprintf(table, "%s\t %s\t %.6g %s/%s\t %d\t\n",
+			amount,
+			alt.Source_amount,
+			rate.Float(), alt.Source_amount.Currency, amount.Currency,
+			len(alt.Paths_computed),
+		)
+	}
+	table.Flush()
+
+	return nil
+}
+
+// parseCurrencies parses a comma separated list of currencies, i.e.
+// "XRP,USD/bitstamp".  An issuer may be an address or nickname.
+func parseCurrencies(arg string) ([]rpc.PathCurrency, error) {
+	if arg == "" {
+		return nil, nil
+	}
+	var currencies []rpc.PathCurrency
+	for _, item := range strings.Split(arg, ",") {
+		parts := strings.Split(item, "/")
+		if len(parts) > 2 || parts[0] == "" {
+			return nil, errors.Errorf("bad currency (%q), expected <currency>[/<issuer>]", item)
+		}
+		_, err := data.NewCurrency(parts[0])
+		if err != nil {
+			return nil, errors.Wrapf(err, "bad currency (%q)", item)
+		}
+		currency := rpc.PathCurrency{Currency: parts[0]}
+		if len(parts) == 2 {
+			if strings.ToUpper(parts[0]) == "XRP" {
+				return nil, errors.Errorf("bad currency (%q), XRP has no issuer", item)
+			}
+			issuer, err := cmd.ParseAccountArg(parts[1:])
+			if err != nil {
+				return nil, errors.Wrapf(err, "bad issuer (%q)", item)
+			}
+			currency.Issuer = issuer[0].Account.String()
+		}
+		currencies = append(currencies, currency)
+	}
+	return currencies, nil
+}
+
+// findPaths returns alternative ways to deliver amount to
+// destination, spending one of currencies (any source holds, when
+// currencies is empty).
+func findPaths(ws *rpc.Websocket, source, destination data.Account, amount data.Amount, currencies []rpc.PathCurrency) ([]rpc.PathAlternative, error) {
+	result, err := ws.RipplePathFind(rpc.PathFind{
+		Source_account:      source.String(),
+		Source_currencies:   currencies,
+		Destination_account: destination.String(),
+		Destination_amount:  amount,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Alternatives) == 0 {
+		return nil, errors.Errorf("no paths found from %s to deliver %s to %s", source, amount, destination)
+	}
+	return result.Alternatives, nil
+}
+
+// cheapestPath returns the alternative which spends least.  Source
+// amounts must be in one currency, to be compared.
+func cheapestPath(alternatives []rpc.PathAlternative) (*rpc.PathAlternative, error) {
+	best := &alternatives[0]
+	for i := range alternatives[1:] {
+		alt := &alternatives[i+1]
+		if alt.Source_amount.Currency != best.Source_amount.Currency {
+			return nil, errors.Errorf("cannot compare alternatives in %s and %s, specify one source currency", best.Source_amount.Currency, alt.Source_amount.Currency)
+		}
+		if alt.Source_amount.Less(*best.Source_amount.Value) {
+			best = alt
+		}
+	}
+	return best, nil
+}
+
+// withSlippage returns amount increased by percent, suitable for
+// SendMax when rates may change before a payment is submitted.  XRP
+// is rounded down to whole drops.
+func withSlippage(amount data.Amount, percent float64) (*data.Amount, error) {
+	basisPoints := int64(math.Round(10000 + percent*100))
+	if amount.IsNative() {
+		drops, err := util.Drops(amount)
+		if err != nil {
+			return nil, err
+		}
+		max := new(big.Int).Mul(new(big.Int).SetUint64(drops), big.NewInt(basisPoints))
+		max.Quo(max, big.NewInt(10000))
+		value, err := data.NewNativeValue(max.Int64())
+		if err != nil {
+			return nil, err
+		}
+		return &data.Amount{Value: value}, nil
+	}
+
+	factor, err := data.NewNonNativeValue(basisPoints, -4)
+	if err != nil {
+		return nil, err
+	}
+	value, err := amount.Value.Multiply(*factor)
+	if err != nil {
+		return nil, err
+	}
+	return &data.Amount{
+		Value:    value,
+		Currency: amount.Currency,
+		Issuer:   amount.Issuer,
+	}, nil
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/dncohen/rcl/rpc"
+	"github.com/rubblelabs/ripple/data"
+)
+
+const testIssuer = "rvYAfWj5gh67oV6fW32ZzP3Aw4Eubs59B"
+
+func testAmount(t *testing.T, amount string) data.Amount {
+	t.Helper()
+	a, err := data.NewAmount(amount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return *a
+}
+
+func TestWithSlippage(t *testing.T) {
+	tests := []struct {
+		amount  string
+		percent float64
+		want    string
+	}{
+		{"100/XRP", 1, "101/XRP"},
+		{"100/XRP", 0, "100/XRP"},
+		{"0.000015/XRP", 1, "0.000015/XRP"},   // 15.15 drops, rounded down
+		{"0.000999/XRP", 0.5, "0.001003/XRP"}, // 1003.995 drops
+		{"100/USD/" + testIssuer, 0.5, "100.5/USD/" + testIssuer},
+		{"0.001/USD/" + testIssuer, 2.25, "0.0010225/USD/" + testIssuer},
+	}
+	for _, test := range tests {
+		got, err := withSlippage(testAmount(t, test.amount), test.percent)
+		if err != nil {
+			t.Errorf("%s plus %g%%: %s", test.amount, test.percent, err)
+			continue
+		}
+		want := testAmount(t, test.want)
+		if got.IsNative() != want.IsNative() || got.Currency != want.Currency || got.Issuer != want.Issuer || got.Value.Rat().Cmp(want.Value.Rat()) != 0 {
+			t.Errorf("%s plus %g%%: expected %s, got %s", test.amount, test.percent, want, got)
+		}
+	}
+}
+
+func TestCheapestPath(t *testing.T) {
+	alternatives := func(amount ...string) []rpc.PathAlternative {
+		var alt []rpc.PathAlternative
+		for _, a := range amount {
+			alt = append(alt, rpc.PathAlternative{Source_amount: testAmount(t, a)})
+		}
+		return alt
+	}
+	tests := []struct {
+		alternatives []rpc.PathAlternative
+		want         int // index of cheapest, or -1 for error
+	}{
+		{alternatives("10/USD/" + testIssuer), 0},
+		{alternatives("10/USD/"+testIssuer, "9.5/USD/"+testIssuer, "11/USD/"+testIssuer), 1},
+		{alternatives("12/XRP", "11/XRP", "10.999999/XRP"), 2},
+		{alternatives("10/USD/"+testIssuer, "10/XRP"), -1},
+	}
+	for i, test := range tests {
+		got, err := cheapestPath(test.alternatives)
+		if test.want < 0 {
+			if err == nil {
+				t.Errorf("test %d: expected error comparing currencies, got %s", i, got.Source_amount)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: %s", i, err)
+			continue
+		}
+		if got != &test.alternatives[test.want] {
+			t.Errorf("test %d: expected %s, got %s", i, test.alternatives[test.want].Source_amount, got.Source_amount)
+		}
+	}
+}
@@ -0,0 +1,170 @@
+// Copyright (C) 2020  David N. Cohen
+// This file is part of github.com/dncohen/rcl
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Operation regularkey
+//
+// Compose an RCL transaction to authorize a regular key, which can
+// sign for the account in place of its master key.  The regular key
+// is an address (or nickname), for instance one created by `rcl-key
+// generate`.
+//
+//   rcl-tx -as treasury regularkey treasury-hot
+//
+// With -clear, the transaction removes the account's regular key.
+//
+// After the transaction is validated, add `regularkey=<nickname>` to
+// the account's config section, so that `rcl-key sign` uses the
+// regular key.
+//
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sync/errgroup"
+	"src.d10.dev/command"
+
+	"github.com/dncohen/rcl/internal/cmd"
+	"github.com/dncohen/rcl/internal/pipeline"
+	"github.com/dncohen/rcl/rpc"
+	"github.com/dncohen/rcl/tx"
+	"github.com/pkg/errors"
+	"github.com/rubblelabs/ripple/data"
+	"github.com/rubblelabs/ripple/websockets"
+)
+
+// account root flag, when master key is disabled
+const lsfDisableMaster = 0x00100000
+
+func init() {
+	command.RegisterOperation(command.Operation{
+		Handler:     opRegularKey,
+		Name:        "regularkey",
+		Syntax:      "regularkey <address> | -clear",
+		Description: `Set or clear the regular key of an RCL account.`,
+	})
+}
+
+func opRegularKey() error {
+	clearFlag := command.OperationFlagSet.Bool("clear", false, "remove the account's regular key")
+	command.CheckUsage(command.ParseOperationFlagSet())
+
+	if *asFlag == "" {
+		return errors.New("operation requires -as <account> flag")
+	}
+
+	args := command.OperationFlagSet.Args()
+	var regularKey *data.Account
+	if *clearFlag {
+		if len(args) != 0 {
+			return errors.New("expected either -clear or regular key address, not both")
+		}
+	} else {
+		if len(args) != 1 {
+			return errors.New("expected regular key address (or -clear)")
+		}
+		tmp, err := cmd.ParseAccountArg(args)
+		if err != nil {
+			return fmt.Errorf("bad regular key (%q): %w", args[0], err)
+		}
+		regularKey = &tmp[0].Account
+	}
+
+	rippled, err := cmd.Rippled()
+	command.Check(err)
+
+	// Learn needed details, i.e. account sequence number.
+	remote, err := websockets.NewRemote(rippled)
+	command.Check(err)
+	defer remote.Close()
+
+	var g errgroup.Group
+	var accountInfo *websockets.AccountInfoResult
+	g.Go(func() error {
+		var err error
+		accountInfo, err = remote.AccountInfo(*asAccount)
+		if err != nil {
+			command.Errorf("Failed to get account_info %s: %s", asAccount, err)
+			return err
+		}
+		return nil
+	})
+	err = g.Wait()
+	command.Check(err)
+
+	if *clearFlag && masterDisabled(accountInfo) {
+		// the network would reject this, but fail early
+		err = requireSignerList(rippled, *asAccount, "remove regular key")
+		command.Check(err)
+	}
+
+	// Prepare to encode transaction output.
+	unsignedOut := make(chan (data.Transaction))
+	g.Go(func() error {
+		return pipeline.EncodeOutput(os.Stdout, unsignedOut)
+	})
+
+	if *memoFlag == "" {
+		memoFlag = nil
+	}
+
+	t, err := tx.NewSetRegularKey(
+		tx.SetAddress(asAccount),
+		tx.SetSequence(*accountInfo.AccountData.Sequence),
+		tx.SetLastLedgerSequence(accountInfo.LedgerSequence+LedgerSequenceInterval),
+		tx.SetFee(txFee()),
+		tx.AddMemo(memoFlag),
+		tx.AddMemo(memohex),
+		tx.SetRegularKey(regularKey),
+		tx.SetCanonicalSig(true),
+	)
+	command.Check(err)
+
+	// marshall the tx to stdout pipeline
+	unsignedOut <- t
+	close(unsignedOut)
+
+	// Wait for all output to be encoded
+	err = g.Wait()
+	command.Check(err)
+
+	return nil
+}
+
+func masterDisabled(info *websockets.AccountInfoResult) bool {
+	flags := info.AccountData.Flags
+	return flags != nil && uint32(*flags)&lsfDisableMaster != 0
+}
+
+// requireSignerList fails unless account has a signer list.  Used to
+// guard against changes that would leave an account unable to sign.
+func requireSignerList(rippled string, account data.Account, action string) error {
+	ws, err := rpc.NewWebsocket(rippled)
+	if err != nil {
+		return err
+	}
+	defer ws.Close()
+
+	signers, err := ws.SignerList(account)
+	if err != nil {
+		return err
+	}
+	if signers == nil {
+		return fmt.Errorf("refusing to %s: %s would have no way to sign (no master key, regular key, or signer list)", action, cmd.FormatAccount(account, nil))
+	}
+	return nil
+}
@@ -30,7 +30,6 @@ import (
 	"src.d10.dev/command"
 
 	"github.com/dncohen/rcl/internal/pipeline"
-	"github.com/rubblelabs/ripple/data"
 )
 
 func init() {
@@ -45,7 +44,7 @@ func init() {
 func opSave() error {
 
 	// decode transactions from stdin
-	txIn := make(chan (data.Transaction))
+	txIn := make(chan pipeline.Item)
 	go func() {
 		err := pipeline.DecodeInput(txIn, os.Stdin)
 		command.Check(err)
@@ -54,13 +53,14 @@ func opSave() error {
 
 	// prepare to encode transactions to stdout, so we can be part of a pipeline.
 	var g errgroup.Group
-	txOut := make(chan (data.Transaction))
+	txOut := make(chan pipeline.Item)
 	g.Go(func() error {
-		return pipeline.EncodeOutput(os.Stdout, txOut)
+		return pipeline.EncodeItems(os.Stdout, txOut)
 	})
 	// Later, we will wait for g to complete.
 
-	for tx := range txIn {
+	for item := range txIn {
+		tx := item.Full()
 		// Encode to file.
 		// TODO put altnet in filename?
 
@@ -77,7 +77,7 @@ func opSave() error {
 			command.Infof("transaction saved as %s.\n", filename)
 
 			// pipe the transaction only if able to save it
-			txOut <- tx
+			txOut <- item
 		}
 	}
 
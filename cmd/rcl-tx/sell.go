@@ -16,7 +16,23 @@
 
 // Operation sell
 //
-// Create an offer to sell one asset or issuance for another.
+// Create an offer to sell one asset or issuance for another.  Specify
+// either both amounts, or the amount to sell and a price per unit:
+//
+//     rcl-tx -as desk sell 100/XRP for 52/USD/bitstamp
+//     rcl-tx -as desk sell 100/XRP at 0.52 USD/bitstamp
+//
+// By default, an offer not filled immediately remains in the order
+// book.  Use -ioc (immediate or cancel) to fill what is possible now,
+// and -fok (fill or kill) to fill all now, or nothing.  A -passive
+// offer does not consume offers at exactly its price.  With -expires,
+// an offer expires at a given time (i.e. "+1h").
+//
+// To replace an existing offer, specify its sequence with -replace.
+// The old offer is cancelled by the same transaction which places the
+// new one.
+//
+// See also the buy operation.
 //
 package main
 
@@ -24,7 +40,11 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"math/big"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"golang.org/x/sync/errgroup"
 	"src.d10.dev/command"
@@ -32,7 +52,6 @@ import (
 	"github.com/dncohen/rcl/internal/cmd"
 	"github.com/dncohen/rcl/internal/pipeline"
 	"github.com/dncohen/rcl/tx"
-	"github.com/pkg/errors"
 	"github.com/rubblelabs/ripple/data"
 	"github.com/rubblelabs/ripple/websockets"
 )
@@ -41,33 +60,63 @@ func init() {
 	command.RegisterOperation(command.Operation{
 		Handler:     opSell,
 		Name:        "sell",
-		Syntax:      "sell <amount> for <amount>",
+		Syntax:      "sell <amount> for <amount> | sell <amount> at <price> <currency>[/<issuer>] [-ioc|-fok] [-passive] [-expires=<time>] [-replace=<sequence>]",
 		Description: `Create an offer to sell one asset or issuance for another.`,
 	})
 }
 
 func opSell() error {
+	return opOffer(true)
+}
 
-	command.CheckUsage(command.ParseOperationFlagSet())
+// opOffer composes an OfferCreate, for either sell or buy operation.
+// A sell offer (tfSell) exchanges all of the amount to sell, even
+// when that accrues more than asked.  A buy offer stops when the
+// amount to buy is acquired.
+func opOffer(sell bool) error {
+	op := "sell"
+	if !sell {
+		op = "buy"
+	}
 
-	argument := command.OperationFlagSet.Args()
+	iocFlag := command.OperationFlagSet.Bool("ioc", false, "immediate or cancel, fill what is possible now and place nothing in the order book")
+	fokFlag := command.OperationFlagSet.Bool("fok", false, "fill or kill, fill entirely now or not at all")
+	passiveFlag := command.OperationFlagSet.Bool("passive", false, "do not consume offers exactly matching this one")
+	expiresFlag := command.OperationFlagSet.String("expires", "", "time offer expires, i.e. \"+1h\" or \"2021-06-30 17:00\"")
+	replaceFlag := command.OperationFlagSet.String("replace", "", "sequence of existing offer to cancel, atomically replacing it")
+
+	argument, err := cmd.ParseInterspersed(command.OperationFlagSet, command.Args()[1:])
+	command.CheckUsage(err)
 
 	if len(argument) < 3 {
-		command.CheckUsage(errors.New("expected arguments: <amount-to-sell> for <amount-to-accrue>"))
+		command.CheckUsage(fmt.Errorf("expected arguments: <amount-to-%s> for <amount> or <amount-to-%s> at <price> <currency>", op, op))
 	}
 
 	fail := false
 
-	takerGets, err := data.NewAmount(argument[0])
+	amount, err := cmd.AmountFromArg(argument[0])
 	if err != nil {
-		command.Errorf("bad amount to sell (%q): %s", argument[0], err)
+		command.Errorf("bad amount to %s (%q): %s", op, argument[0], err)
 		fail = true
 	}
 
-	takerPays, err := data.NewAmount(argument[2])
-	if err != nil {
-		command.Errorf("bad amount to accrue (%q): %s", argument[2], err)
-		fail = true
+	// Make the user type "for" or "at", less likely to mistakenly reverse the amounts.
+	var counter *data.Amount
+	switch {
+	case argument[1] == "for" && len(argument) == 3:
+		counter, err = cmd.AmountFromArg(argument[2])
+		if err != nil {
+			command.Errorf("bad amount in exchange (%q): %s", argument[2], err)
+			fail = true
+		}
+	case argument[1] == "at" && len(argument) == 4:
+		counter, err = priceAmount(argument[0], argument[2], argument[3])
+		if err != nil {
+			command.Errorf("bad price (%q %q): %s", argument[2], argument[3], err)
+			fail = true
+		}
+	default:
+		command.Check(fmt.Errorf("Expected `%s <amount> for <amount>` or `%s <amount> at <price> <currency>`.", op, op))
 	}
 
 	// -as <account> is parsed in main.go
@@ -76,16 +125,63 @@ func opSell() error {
 		fail = true
 	}
 
+	var flags data.TransactionFlag
+	if sell {
+		flags |= data.TxSell
+	}
+	if *iocFlag && *fokFlag {
+		command.Errorf("use either -ioc or -fok, not both")
+		fail = true
+	}
+	if *iocFlag {
+		flags |= data.TxImmediateOrCancel
+	}
+	if *fokFlag {
+		flags |= data.TxFillOrKill
+	}
+	if *passiveFlag {
+		flags |= data.TxPassive
+	}
+
+	var options []func(data.Transaction) error
+	if *expiresFlag != "" {
+		t, err := cmd.ParseTime(*expiresFlag)
+		if err == nil && !t.After(time.Now()) {
+			err = fmt.Errorf("%s is not in the future", t)
+		}
+		var expiration uint32
+		if err == nil {
+			expiration, err = cmd.RippleTime(t)
+		}
+		if err != nil {
+			command.Errorf("bad -expires (%q): %s", *expiresFlag, err)
+			fail = true
+		}
+		options = append(options, tx.SetExpiration(expiration))
+	}
+	if *replaceFlag != "" {
+		seq, err := strconv.ParseUint(*replaceFlag, 10, 32)
+		if err != nil {
+			command.Errorf("bad -replace (%q), expected offer sequence: %s", *replaceFlag, err)
+			fail = true
+		}
+		options = append(options, tx.SetOfferSequence(uint32(seq)))
+	}
+
 	if fail {
 		command.Exit()
 	}
 
-	// Make the user type "for", less likely to mistakenly reverse the amounts.
-	if argument[1] != "for" {
-		command.Check(errors.New("Expected `sell <amount> for <amount>`."))
+	// TakerGets is what we give, TakerPays what we get.
+	takerGets, takerPays := amount, counter
+	if !sell {
+		takerGets, takerPays = counter, amount
+	}
+	if flags != 0 {
+		options = append(options, tx.SetFlags(flags))
 	}
 
-	command.Infof("sell %s from %s in exchange for %s...\n", takerGets, asAccount, takerPays)
+	command.Infof("%s %s from %s in exchange for %s...\n", op, amount, asAccount, counter)
 
 	rippled, err := cmd.Rippled()
 	command.Check(err)
@@ -174,18 +270,25 @@ func opSell() error {
 	})
 
 	// Prepare transaction.
-	offer, err := tx.NewOfferCreate(
+	options = append([]func(data.Transaction) error{
 		tx.SetAddress(asAccount),
+		tx.SetSourceTag(asTag),
 		tx.SetSequence(*accountInfo.AccountData.Sequence),
-		tx.SetLastLedgerSequence(accountInfo.LedgerSequence+LedgerSequenceInterval),
-		tx.SetFee(12),
+		tx.SetLastLedgerSequence(accountInfo.LedgerSequence + LedgerSequenceInterval),
+		tx.SetFee(txFee()),
+
+		tx.AddMemo(memoFlag),
+		tx.AddMemo(memohex),
 
 		tx.SetTakerPays(takerPays),
 		tx.SetTakerGets(takerGets),
-		tx.SetFlags(data.TxSell),
 
 		tx.SetCanonicalSig(true),
-	)
+	}, options...)
+	offer, err := tx.NewOfferCreate(options...)
+	if err != nil {
+		command.Check(fmt.Errorf("failed to prepare offer: %w", err))
+	}
 
 	// TODO: is it necessary to clean up the hash that rubblelabs puts into unsigned tx?
 	// "hash":"0000000000000000000000000000000000000000000000000000000000000000"
@@ -207,3 +310,41 @@ func opSell() error {
 
 	return nil
 }
+
+// priceAmount returns the amount, in currency, of amountArg at price
+// per unit.  For example, "100/XRP" at "0.52" "USD/bitstamp" is
+// 52/USD/<bitstamp address>.  Currency may name an issuer by address
+// or nickname.
+func priceAmount(amountArg, priceArg, currencyArg string) (*data.Amount, error) {
+	quantity, ok := new(big.Rat).SetString(strings.SplitN(amountArg, "/", 2)[0])
+	if !ok {
+		return nil, fmt.Errorf("bad amount (%q)", amountArg)
+	}
+	price, ok := new(big.Rat).SetString(priceArg)
+	if !ok || price.Sign() <= 0 {
+		return nil, fmt.Errorf("bad price (%q), expected positive number", priceArg)
+	}
+	total := new(big.Rat).Mul(quantity, price)
+	value := formatDecimal(total, isXRP(currencyArg))
+	return cmd.AmountFromArg(value + "/" + currencyArg)
+}
+
+// isXRP returns true when a currency argument, i.e. "XRP" or
+// "USD/bitstamp", is XRP.
+func isXRP(currencyArg string) bool {
+	return strings.ToUpper(strings.SplitN(currencyArg, "/", 2)[0]) == "XRP"
+}
+
+// formatDecimal formats a value for an amount argument.  XRP has at
+// most 6 decimal places (drops).
+func formatDecimal(r *big.Rat, xrp bool) string {
+	precision := 15
+	if xrp {
+		precision = 6
+	}
+	value := r.FloatString(precision)
+	if strings.Contains(value, ".") {
+		value = strings.TrimSuffix(strings.TrimRight(value, "0"), ".")
+	}
+	return value
+}
@@ -18,17 +18,32 @@
 //
 // Send XRP or issuance.
 //
+// Payments of an issuance, and payments with -from, are composed with
+// paths found by ripple_path_find.  Of the alternatives found, the
+// cheapest is used, and SendMax allows -slippage (percent) beyond its
+// estimated cost.  For example, to pay a supplier in euro, spending
+// USD:
+//
+//     rcl-tx -as treasury send supplier 100/EUR/bitstamp -from=USD
+//
+// Without -from, an issuance is paid in the same currency (from any
+// issuer).  Use the quote operation to compare alternatives before
+// sending.  With -sendmax, no pathfinding is done; the payment uses
+// default paths only.
+//
 package main
 
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"golang.org/x/sync/errgroup"
 	"src.d10.dev/command"
 
 	"github.com/dncohen/rcl/internal/cmd"
 	"github.com/dncohen/rcl/internal/pipeline"
+	"github.com/dncohen/rcl/rpc"
 	"github.com/dncohen/rcl/tx"
 	"github.com/pkg/errors"
 	"github.com/rubblelabs/ripple/data"
@@ -43,7 +58,7 @@ func init() {
 	command.RegisterOperation(command.Operation{
 		Handler:     opSend,
 		Name:        "send",
-		Syntax:      "send  <beneficiary> <amount>",
+		Syntax:      "send  <beneficiary> <amount> [-from=<currency>[/<issuer>]] [-slippage=<percent>] [-sendmax=<amount>]",
 		Description: `Send an RCL asset or issuance from one account to another.`,
 	})
 }
@@ -51,6 +66,8 @@ func init() {
 func opSend() error {
 
 	sendmaxFlag := command.OperationFlagSet.String("sendmax", "", "Specify SendMax, allows cross-currency payment")
+	fromFlag := command.OperationFlagSet.String("from", "", "currency to spend, for cross-currency payment (found by pathfinding)")
+	slippageFlag := command.OperationFlagSet.Float64("slippage", 1, "percent SendMax may exceed estimated cost, when pathfinding")
 
 	command.CheckUsage(command.ParseOperationFlagSet())
 
@@ -67,6 +84,20 @@ func opSend() error {
 		}
 	}
 
+	if sendMax != nil && *fromFlag != "" {
+		command.CheckUsage(errors.New("use either -sendmax or -from, not both"))
+	}
+	if *slippageFlag < 0 {
+		command.CheckUsage(fmt.Errorf("bad slippage (%g), expected percent >= 0", *slippageFlag))
+	}
+	currencies, err := parseCurrencies(*fromFlag)
+	if err != nil {
+		command.Check(err)
+	}
+	if len(currencies) > 1 {
+		command.CheckUsage(errors.New("send requires one -from currency, see quote operation to compare"))
+	}
+
 	argument := command.OperationFlagSet.Args()
 	if len(argument) != 2 {
 		command.CheckUsage(errors.New("operation requires <destination> and <amount> arguments"))
@@ -131,6 +162,27 @@ func opSend() error {
 		command.V(1).Infof("using %s as %s issuer", beneficiary, amount.Currency)
 		amount.Issuer = beneficiary
 	}
+
+	// Cross-currency, or issuance, payments need paths.
+	var paths data.PathSet
+	if sendMax == nil && (!amount.IsNative() || (len(currencies) > 0 && strings.ToUpper(currencies[0].Currency) != "XRP")) {
+		if len(currencies) == 0 {
+			currencies = []rpc.PathCurrency{{Currency: amount.Currency.String()}}
+		}
+		ws, err := rpc.NewWebsocket(rippled)
+		command.Check(err)
+		alternatives, err := findPaths(ws, *asAccount, beneficiary, *amount, currencies)
+		ws.Close()
+		command.Check(err)
+
+		alt, err := cheapestPath(alternatives)
+		command.Check(err)
+		paths, err = alt.PathSet()
+		command.Check(err)
+		sendMax, err = withSlippage(alt.Source_amount, *slippageFlag)
+		command.Check(err)
+		command.V(1).Infof("pathfinding: %d alternatives, cheapest costs %s (sendmax %s)", len(alternatives), alt.Source_amount, sendMax)
+	}
 	if sendMax == nil && !amount.IsNative() { // No sendmax on XRP payments
 		sendMax = amount
 	}
@@ -143,7 +195,7 @@ func opSend() error {
 		tx.SetSourceTag(asTag),
 		tx.SetSequence(*accountInfo.AccountData.Sequence),
 		tx.SetLastLedgerSequence(accountInfo.LedgerSequence+LedgerSequenceInterval),
-		tx.SetFee(12), // TODO
+		tx.SetFee(txFee()),
 
 		tx.AddMemo(memoFlag), // TODO support multiple memo fields
 		tx.AddMemo(memohex),
@@ -151,6 +203,7 @@ func opSend() error {
 		// Simple payment, source and destination currency the same.
 		tx.SetAmount(amount),
 		tx.SetSendMax(sendMax),
+		tx.SetPaths(paths),
 
 		tx.SetDestination(beneficiary),
 		tx.SetDestinationTag(beneficiaryTag),
@@ -18,12 +18,38 @@
 //
 // Compose an RCL transaction to change account settings.
 //
+// Each AccountSet flag has a corresponding command line flag, i.e.
+// -requiredest, -requireauth, -disallowxrp, -disablemaster,
+// -accounttxnid, -nofreeze, -globalfreeze, -defaultripple and
+// -depositauth.  Use `-<flag>` (or `-<flag>=true`) to set, and
+// `-<flag>=false` to clear.  Flags not mentioned are unchanged.  A
+// transaction can set one flag and clear one flag, so when more flags
+// change, set composes several transactions, with consecutive
+// sequence numbers.
+//
+// Fields include -domain, -messagekeyhex, -transferrate (percent fee
+// on transfers of the account's issuances, i.e. 0.2 for 0.2%, or 0 to
+// remove), -ticksize (3 to 15, or 0 to remove), and -emailhash (email
+// address, or md5 hash in hex).
+//
+//   rcl-tx -as issuer set -defaultripple -transferrate=0.2 -ticksize=5
+//
+// Some changes are irreversible or risky, for instance -nofreeze,
+// which can never be cleared.  The operation asks for confirmation of
+// these, unless -yes is given.
+//
+// With -disablemaster, the account's master key can no longer sign.
+// The operation fails unless the account has a regular key or signer
+// list, so that some other key can sign for the account.
+//
 package main
 
 import (
 	"encoding/hex"
 	"fmt"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 
 	"golang.org/x/sync/errgroup"
@@ -32,6 +58,7 @@ import (
 	"github.com/dncohen/rcl/internal/cmd"
 	"github.com/dncohen/rcl/internal/pipeline"
 	"github.com/dncohen/rcl/tx"
+	"github.com/dncohen/rcl/util"
 	"github.com/pkg/errors"
 	"github.com/rubblelabs/ripple/data"
 	"github.com/rubblelabs/ripple/websockets"
@@ -45,15 +72,54 @@ func init() {
 	command.RegisterOperation(command.Operation{
 		Handler:     opSet,
 		Name:        "set",
-		Syntax:      "set ",
+		Syntax:      "set [-<flag>[=false] ...] [-domain=<domain>] [-transferrate=<percent>] [-ticksize=<int>] [-emailhash=<email>] [-messagekeyhex=<hex>]",
 		Description: `Set a flag or field on an RCL account.`,
 	})
 }
 
+// flag.Value that distinguishes unchanged from true or false
+type triState struct {
+	set   bool
+	value bool
+}
+
+func (t *triState) String() string {
+	if t == nil || !t.set {
+		return ""
+	}
+	return strconv.FormatBool(t.value)
+}
+
+func (t *triState) Set(s string) error {
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return err
+	}
+	t.set, t.value = true, v
+	return nil
+}
+
+func (t *triState) IsBoolFlag() bool { return true }
+
 func opSet() error {
 
 	domainFlag := command.OperationFlagSet.String("domain", unchanged, "The domain that owns this account, in lower case.")
 	messagekeyhexFlag := command.OperationFlagSet.String("messagekeyhex", unchanged, "Hexidecimal encoded public key for sending encrypted messages to this account.")
+	transferRateFlag := command.OperationFlagSet.String("transferrate", unchanged, "Fee, in percent (i.e. 0.2), charged on transfers of this account's issuances; 0 to remove.")
+	tickSizeFlag := command.OperationFlagSet.Int("ticksize", -1, "Significant digits of exchange rates of offers involving this account's issuances (3 to 15); 0 to remove.")
+	emailHashFlag := command.OperationFlagSet.String("emailhash", unchanged, "Email address (hashed with md5) or hexidecimal md5 hash; empty to remove.")
+	yesFlag := command.OperationFlagSet.Bool("yes", false, "Do not ask for confirmation of irreversible or risky changes.")
+
+	flagState := make(map[string]*triState)
+	for name, asf := range tx.AccountSetFlag {
+		flagState[name] = &triState{}
+		usage := fmt.Sprintf("Set (or, with =false, clear) flag %d.", asf)
+		if name == "disablemaster" {
+			usage += " Requires regular key or signer list."
+		}
+		command.OperationFlagSet.Var(flagState[name], name, usage)
+	}
+
 	command.CheckUsage(command.ParseOperationFlagSet())
 
 	if *asFlag == "" {
@@ -77,6 +143,41 @@ func opSet() error {
 		}
 	}
 
+	var transferRate *uint32
+	if *transferRateFlag != unchanged {
+		rate, err := tx.TransferRate(strings.TrimSuffix(*transferRateFlag, "%"))
+		command.Check(err)
+		transferRate = &rate
+	}
+
+	var tickSize *uint8
+	if *tickSizeFlag != -1 {
+		if *tickSizeFlag != 0 && (*tickSizeFlag < 3 || *tickSizeFlag > 15) {
+			command.Check(fmt.Errorf("bad ticksize (%d), expected 3 to 15 (or 0 to remove)", *tickSizeFlag))
+		}
+		size := uint8(*tickSizeFlag)
+		tickSize = &size
+	}
+
+	// flags to set and clear, in numeric order
+	var setFlag, clearFlag []uint32
+	for name, state := range flagState {
+		if !state.set {
+			continue
+		}
+		asf := tx.AccountSetFlag[name]
+		if state.value {
+			setFlag = append(setFlag, asf)
+		} else {
+			if asf == tx.AsfNoFreeze {
+				command.Check(errors.New("nofreeze cannot be cleared, once set"))
+			}
+			clearFlag = append(clearFlag, asf)
+		}
+	}
+	sort.Slice(setFlag, func(i, j int) bool { return setFlag[i] < setFlag[j] })
+	sort.Slice(clearFlag, func(i, j int) bool { return clearFlag[i] < clearFlag[j] })
+
 	rippled, err := cmd.Rippled()
 	command.Check(err)
 
@@ -101,6 +202,21 @@ func opSet() error {
 	err = g.Wait()
 	command.Check(err)
 
+	for _, asf := range setFlag {
+		if asf == tx.AsfDisableMaster && accountInfo.AccountData.RegularKey == nil {
+			err = requireSignerList(rippled, *asAccount, "disable master key")
+			command.Check(err)
+		}
+
+		warning, ok := tx.AccountSetWarning[asf]
+		if ok && !*yesFlag {
+			fmt.Fprintf(os.Stderr, "WARNING: %s\nSet flag %d on %s? (yes/no) ", warning, asf, cmd.FormatAccount(*asAccount, nil))
+			if !util.AskForConfirmation() {
+				command.Check(errors.New("not confirmed"))
+			}
+		}
+	}
+
 	// Prepare to encode transaction output.
 	unsignedOut := make(chan (data.Transaction))
 	g.Go(func() error {
@@ -111,26 +227,55 @@ func opSet() error {
 	if *domainFlag == unchanged {
 		domainFlag = nil
 	}
+	if *emailHashFlag == unchanged {
+		emailHashFlag = nil
+	}
 	if *memoFlag == "" {
 		memoFlag = nil
 	}
 
-	t, err := tx.NewAccountSet(
-		tx.SetAddress(asAccount),
-		tx.SetSequence(*accountInfo.AccountData.Sequence),
-		tx.SetLastLedgerSequence(accountInfo.LedgerSequence+LedgerSequenceInterval),
-		tx.SetFee(12),
-		tx.AddMemo(memoFlag), // TODO support multiple memo fields
-		tx.AddMemo(memohex),
-		tx.SetDomain(domainFlag),
-		tx.SetMessageKey(messageKey),
-		tx.SetCanonicalSig(true),
-	)
+	// one transaction per pair of set and clear flags
+	count := len(setFlag)
+	if len(clearFlag) > count {
+		count = len(clearFlag)
+	}
+	if count == 0 {
+		count = 1
+	}
 
-	command.Check(err)
+	for i := 0; i < count; i++ {
+		options := []func(data.Transaction) error{
+			tx.SetAddress(asAccount),
+			tx.SetSequence(*accountInfo.AccountData.Sequence + uint32(i)),
+			tx.SetLastLedgerSequence(accountInfo.LedgerSequence + LedgerSequenceInterval),
+			tx.SetFee(txFee()),
+			tx.AddMemo(memoFlag), // TODO support multiple memo fields
+			tx.AddMemo(memohex),
+			tx.SetCanonicalSig(true),
+		}
+		if i == 0 {
+			// fields change only once
+			options = append(options,
+				tx.SetDomain(domainFlag),
+				tx.SetMessageKey(messageKey),
+				tx.SetTransferRate(transferRate),
+				tx.SetTickSize(tickSize),
+				tx.SetEmailHash(emailHashFlag),
+			)
+		}
+		if i < len(setFlag) {
+			options = append(options, tx.SetAccountFlag(setFlag[i]))
+		}
+		if i < len(clearFlag) {
+			options = append(options, tx.ClearAccountFlag(clearFlag[i]))
+		}
 
-	// marshall the tx to stdout pipeline
-	unsignedOut <- t
+		t, err := tx.NewAccountSet(options...)
+		command.Check(err)
+
+		// marshall the tx to stdout pipeline
+		unsignedOut <- t
+	}
 	close(unsignedOut)
 
 	// Wait for all output to be encoded
@@ -0,0 +1,154 @@
+// Copyright (C) 2020  David N. Cohen
+// This file is part of github.com/dncohen/rcl
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Operation signers
+//
+// Compose an RCL transaction to set (or delete) an account's signer
+// list, enabling multi-signing.  Each signer is an address or
+// nickname, optionally followed by ":" and weight (default 1).  For
+// example, to require any two of three signers:
+//
+//   rcl-tx -as treasury signers -quorum=2 alice bob carol:1
+//
+// A quorum of 0, with no signers, deletes the signer list.
+//
+//   rcl-tx -as treasury signers -quorum=0
+//
+// Note that deleting the signer list may leave the account unable to
+// sign, if its master key is disabled and it has no regular key.
+//
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+	"src.d10.dev/command"
+
+	"github.com/dncohen/rcl/internal/cmd"
+	"github.com/dncohen/rcl/internal/pipeline"
+	"github.com/dncohen/rcl/tx"
+	"github.com/pkg/errors"
+	"github.com/rubblelabs/ripple/data"
+	"github.com/rubblelabs/ripple/websockets"
+)
+
+func init() {
+	command.RegisterOperation(command.Operation{
+		Handler:     opSigners,
+		Name:        "signers",
+		Syntax:      "signers -quorum=<int> [<signer>[:<weight>] ...]",
+		Description: `Set the signer list of an RCL account.`,
+	})
+}
+
+func opSigners() error {
+	quorumFlag := command.OperationFlagSet.Int("quorum", -1, "total weight of signatures required (0 deletes signer list)")
+	command.CheckUsage(command.ParseOperationFlagSet())
+
+	if *asFlag == "" {
+		return errors.New("operation requires -as <account> flag")
+	}
+	if *quorumFlag < 0 {
+		return errors.New("operation requires -quorum=<int> flag")
+	}
+
+	options := []func(data.Transaction) error{
+		tx.SetSignerQuorum(uint32(*quorumFlag)),
+	}
+	for _, arg := range command.OperationFlagSet.Args() {
+		nick, weight, err := parseSignerArg(arg)
+		if err != nil {
+			return err
+		}
+		signer, err := cmd.ParseAccountArg([]string{nick})
+		if err != nil {
+			return fmt.Errorf("bad signer (%q): %w", arg, err)
+		}
+		options = append(options, tx.AddSignerEntry(signer[0].Account, weight))
+	}
+
+	rippled, err := cmd.Rippled()
+	command.Check(err)
+
+	// Learn needed details, i.e. account sequence number.
+	remote, err := websockets.NewRemote(rippled)
+	command.Check(err)
+	defer remote.Close()
+
+	var g errgroup.Group
+	var accountInfo *websockets.AccountInfoResult
+	g.Go(func() error {
+		var err error
+		accountInfo, err = remote.AccountInfo(*asAccount)
+		if err != nil {
+			command.Errorf("Failed to get account_info %s: %s", asAccount, err)
+			return err
+		}
+		return nil
+	})
+	err = g.Wait()
+	command.Check(err)
+
+	// Prepare to encode transaction output.
+	unsignedOut := make(chan (data.Transaction))
+	g.Go(func() error {
+		return pipeline.EncodeOutput(os.Stdout, unsignedOut)
+	})
+
+	if *memoFlag == "" {
+		memoFlag = nil
+	}
+
+	options = append([]func(data.Transaction) error{
+		tx.SetAddress(asAccount),
+		tx.SetSequence(*accountInfo.AccountData.Sequence),
+		tx.SetLastLedgerSequence(accountInfo.LedgerSequence + LedgerSequenceInterval),
+		tx.SetFee(txFee()),
+		tx.AddMemo(memoFlag),
+		tx.AddMemo(memohex),
+		tx.SetCanonicalSig(true),
+	}, options...)
+
+	t, err := tx.NewSignerListSet(options...)
+	command.Check(err)
+
+	// marshall the tx to stdout pipeline
+	unsignedOut <- t
+	close(unsignedOut)
+
+	// Wait for all output to be encoded
+	err = g.Wait()
+	command.Check(err)
+
+	return nil
+}
+
+// parse "<signer>[:<weight>]"
+func parseSignerArg(arg string) (string, uint16, error) {
+	i := strings.LastIndex(arg, ":")
+	if i == -1 {
+		return arg, 1, nil
+	}
+	weight, err := strconv.ParseUint(arg[i+1:], 10, 16)
+	if err != nil || weight == 0 {
+		return arg, 0, fmt.Errorf("bad signer weight (%q), expected positive integer", arg)
+	}
+	return arg[:i], uint16(weight), nil
+}
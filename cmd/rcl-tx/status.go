@@ -0,0 +1,162 @@
+// Copyright (C) 2020  David N. Cohen
+// This file is part of github.com/dncohen/rcl
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Operation status
+//
+// Status shows transactions recorded in the submit journal, and
+// learns the outcome of those not yet final, for instance after
+// submit was interrupted.
+//
+//     rcl-tx status [-pending] [<journal dir>]
+//
+// Each pending transaction is looked up by hash.  A validated
+// transaction is final, whether its result is success or failure.  A
+// transaction not validated is final (expired) only when the server's
+// ledger history, without gaps, spans from the ledger before it was
+// first submitted through its LastLedgerSequence.  Otherwise it
+// remains pending; try again later, or with a full history server.
+//
+// The journal directory defaults to the one used by submit.
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"src.d10.dev/command"
+
+	"github.com/dncohen/rcl/internal/cmd"
+	"github.com/dncohen/rcl/internal/journal"
+	"github.com/dncohen/rcl/rpc"
+	"github.com/dncohen/rcl/util"
+	"github.com/pkg/errors"
+	"github.com/rubblelabs/ripple/data"
+	"github.com/rubblelabs/ripple/websockets"
+)
+
+func init() {
+	command.RegisterOperation(command.Operation{
+		Handler:     opStatus,
+		Name:        "status",
+		Syntax:      "status [-pending] [<journal dir>]",
+		Description: `Show, and determine the final outcome of, journaled transactions.`,
+	})
+}
+
+func opStatus() error {
+	pendingFlag := command.OperationFlagSet.Bool("pending", false, "show only transactions not yet final")
+
+	argument, err := cmd.ParseInterspersed(command.OperationFlagSet, command.Args()[1:])
+	command.CheckUsage(err)
+	if len(argument) > 1 {
+		command.CheckUsage(errors.New("expected at most one argument, journal directory"))
+	}
+
+	var dir string
+	if len(argument) == 1 {
+		dir = argument[0]
+	} else {
+		dir, err = cmd.Journal()
+		command.Check(err)
+	}
+	if _, err := os.Stat(dir); err != nil {
+		command.Check(fmt.Errorf("journal not found: %w", err))
+	}
+	j, err := journal.Open(dir)
+	command.Check(err)
+
+	entries, err := j.Entries()
+	command.Check(err)
+
+	var remote *websockets.Remote
+	var history *[2]uint32 // complete ledgers, learned once
+	for _, entry := range entries {
+		if entry.Final() {
+			continue
+		}
+		if remote == nil {
+			rippled, err := cmd.Rippled()
+			command.Check(err)
+			remote, err = websockets.NewRemote(rippled)
+			if err != nil {
+				command.Check(fmt.Errorf("failed to connect to %q: %w", rippled, err))
+			}
+			defer remote.Close()
+
+			ws, err := rpc.NewWebsocket(rippled)
+			command.Check(err)
+			info, err := ws.ServerInfo()
+			ws.Close()
+			command.Check(err)
+			min, max, err := util.ParseCompleteLedgers(info.Complete_ledgers)
+			command.Check(err)
+			history = &[2]uint32{min, max}
+			command.V(1).Infof("%s has complete ledgers %d-%d", rippled, min, max)
+		}
+
+		err := resolveEntry(j, remote, entry, history[0], history[1])
+		if err != nil {
+			command.Error(fmt.Sprintf("%s (%s/%d): %s", entry.Hash, entry.Account, entry.Sequence, err))
+		}
+	}
+
+	table := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', tabwriter.Debug)
+	fmt.Fprintln(table, "Hash\t Account\t Sequence\t Type\t Status\t Result\t Ledger\t")
+	for _, entry := range entries {
+		if *pendingFlag && entry.Final() {
+			continue
+		}
+		ledger := ""
+		if entry.Ledger != 0 {
+			ledger = fmt.Sprint(entry.Ledger)
+		}
+		fmt.Fprintf(table, "%s\t %s\t %d\t %s\t %s\t %s\t %s\t\n",
+			entry.Hash, entry.Account, entry.Sequence, entry.Type, entry.Status, entry.Result, ledger)
+	}
+	table.Flush()
+
+	return nil
+}
+
+// resolveEntry updates a pending entry, if its outcome is now final.
+// Min and max are the server's most recent complete ledger history.
+func resolveEntry(j *journal.Journal, remote *websockets.Remote, entry *journal.Entry, min, max uint32) error {
+	var hash data.Hash256
+	b, err := hex.DecodeString(entry.Hash)
+	if err != nil || len(b) != len(hash) {
+		return fmt.Errorf("bad hash in journal")
+	}
+	copy(hash[:], b)
+
+	result, err := remote.Tx(hash)
+	if err == nil && result.Validated {
+		entry.Result = result.MetaData.TransactionResult.String()
+		entry.Ledger = result.LedgerSequence
+		return j.Update(entry, journal.Validated, "found by status")
+	}
+	if err != nil && !util.IsTxnNotFound(err) {
+		return fmt.Errorf("tx lookup failed: %w", err)
+	}
+
+	// Not validated.  Expired if history proves it cannot be.
+	if min <= entry.FirstLedger+1 && max >= entry.LastLedgerSequence {
+		return j.Update(entry, journal.Expired, fmt.Sprintf("not validated in ledgers %d-%d", entry.FirstLedger+1, entry.LastLedgerSequence))
+	}
+	command.V(1).Infof("%s (%s/%d) pending: server history %d-%d does not span ledgers %d-%d", entry.Hash, entry.Account, entry.Sequence, min, max, entry.FirstLedger+1, entry.LastLedgerSequence)
+	return nil
+}
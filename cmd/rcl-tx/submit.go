@@ -18,19 +18,58 @@
 //
 // Submit command broadcasts signed transactions to a rippled server.
 //
+// Transactions are grouped by account, and each account's are
+// submitted in sequence order, so a batch (i.e. composed by batch or
+// ladder operations) is not rejected for arriving out of order.
+// Different accounts' transactions are submitted concurrently.
+//
+// A retriable result (ter or tel, for instance terPRE_SEQ) is
+// resubmitted after the next validated ledger, until the
+// transaction's LastLedgerSequence passes.  A queued result
+// (terQUEUED) is awaited like any other.  A hard failure (tem or tef)
+// stops the account's chain: its later transactions are not
+// submitted.
+//
+// When all results are final, submit writes a report to stdout, in
+// JSON format, showing each transaction's validated result, ledger
+// and fee paid.  Exit status is non-zero unless all succeeded.
+//
+// Each transaction is recorded in a journal before it is submitted,
+// with its signed blob, and as its status changes.  If submit is
+// interrupted, run it again with the same input to resume (final
+// transactions are not submitted again), or use the status operation
+// to learn the outcome of journaled transactions.  The journal
+// directory is `journal=<dir>` in configuration, by default
+// $HOME/.config/rcl/journal.
+//
+// A transaction not found in the server's ledger history, with no
+// gaps, from before it was submitted through its LastLedgerSequence,
+// has expired.  If the server's history has gaps, the outcome cannot
+// be known.  So with -timeout, submit stops waiting after the given
+// duration, as it does when interrupted (i.e. control-C), and reports
+// the transactions still pending.
+//
 package main
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"sync"
 
-	"golang.org/x/sync/errgroup"
 	"src.d10.dev/command"
 
 	"github.com/dncohen/rcl/internal/cmd"
+	"github.com/dncohen/rcl/internal/journal"
 	"github.com/dncohen/rcl/internal/pipeline"
 	"github.com/dncohen/rcl/util"
 	"github.com/rubblelabs/ripple/data"
+	"github.com/rubblelabs/ripple/websockets"
 )
 
 func init() {
@@ -42,14 +81,47 @@ func init() {
 	})
 }
 
+// submitReport is the outcome of one submitted transaction.
+type submitReport struct {
+	Hash      string `json:"hash"`
+	Type      string `json:"type"`
+	Account   string `json:"account"`
+	Sequence  uint32 `json:"sequence"`
+	Tentative string `json:"tentative,omitempty"` // last tentative result
+	Result    string `json:"result"`              // validated result, or why not final
+	Validated bool   `json:"validated"`
+	Ledger    uint32 `json:"ledger,omitempty"`
+	Fee       uint64 `json:"fee"` // drops
+}
+
+func (report *submitReport) succeeded() bool {
+	return report.Validated && strings.HasPrefix(report.Result, "tes")
+}
+
 func opSubmit() error {
 
+	journalFlag := command.OperationFlagSet.String("journal", "", "directory where submitted transactions are journaled (default from configuration)")
+	timeoutFlag := command.OperationFlagSet.Duration("timeout", 0, "stop waiting for results after duration, i.e. \"10m\" (default no timeout)")
+
 	command.CheckUsage(command.ParseOperationFlagSet())
 
+	dir := *journalFlag
+	if dir == "" {
+		var err error
+		dir, err = cmd.Journal()
+		command.Check(err)
+	}
+	j, err := journal.Open(dir)
+	if err != nil {
+		command.Check(fmt.Errorf("failed to open journal: %w", err))
+	}
+	command.V(1).Infof("journal %q", j)
+
 	// TODO(dnc): support files or pipelin
 
-	// Read incoming signed transactions from stdin
-	signedIn := make(chan (data.Transaction))
+	// Read incoming signed transactions from stdin.  All are read
+	// before any is submitted, in order to submit in sequence order.
+	signedIn := make(chan pipeline.Item)
 	go func() {
 		defer close(signedIn)
 
@@ -57,6 +129,22 @@ func opSubmit() error {
 		command.Check(err)
 	}()
 
+	var accounts []data.Account // in order of appearance
+	chains := make(map[data.Account][]data.Transaction)
+	count := 0
+	for item := range signedIn {
+		t := item.Full() // with Signers, if multi-signed
+		account := t.GetBase().Account
+		if _, ok := chains[account]; !ok {
+			accounts = append(accounts, account)
+		}
+		chains[account] = append(chains[account], t)
+		count++
+	}
+	if count == 0 {
+		command.Check(fmt.Errorf("no transactions to submit"))
+	}
+
 	rippled, err := cmd.Rippled()
 	command.Check(err)
 
@@ -69,42 +157,233 @@ func opSubmit() error {
 	go subscription.Loop()
 	command.V(1).Infof("connected to %q", rippled) // verbose
 
-	// errgroup in order to wait for results of all submitted tx.
-	var g errgroup.Group
+	// Stop waiting when interrupted, or after timeout.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if *timeoutFlag > 0 {
+		ctx, cancel = context.WithTimeout(ctx, *timeoutFlag)
+		defer cancel()
+	}
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		command.Infof("interrupted, no longer waiting for results")
+		cancel()
+		signal.Stop(interrupt) // again to exit immediately
+	}()
+
+	// Submit each account's transactions, concurrently.
+	reports := make(map[data.Account][]*submitReport)
+	var mutex sync.Mutex
+	var wg sync.WaitGroup
+	for _, account := range accounts {
+		chain := chains[account]
+		sort.SliceStable(chain, func(i, j int) bool {
+			return chain[i].GetBase().Sequence < chain[j].GetBase().Sequence
+		})
 
-	// Submit all transaction decoded
-	for tx := range signedIn {
-		tx := tx // scope (needed ?)
+		wg.Add(1)
+		go func(account data.Account, chain []data.Transaction) {
+			defer wg.Done()
+			r := submitChain(ctx, subscription, j, chain)
+			mutex.Lock()
+			defer mutex.Unlock()
+			reports[account] = r
+		}(account, chain)
+	}
 
-		// TODO(dnc): support interactive mode where user is prompted before submitting.
+	// Wait for result of each submitted transaction.
+	wg.Wait()
 
-		g.Go(func() error {
-			tx := tx // Scope (needed?)
-			result, err := subscription.SubmitWait(tx)
-			if err != nil {
-				return fmt.Errorf("failed to submit %s (%s): %w", tx.GetType(), tx.GetHash(), err)
+	var all []*submitReport
+	failed := 0
+	for _, account := range accounts {
+		for _, report := range reports[account] {
+			all = append(all, report)
+			if !report.succeeded() {
+				failed++
 			}
+		}
+	}
+
+	b, err := json.MarshalIndent(all, "", "\t")
+	command.Check(err)
+	fmt.Println(string(b)) // stdout
 
-			if !result.Validated {
-				return fmt.Errorf("%s transaction %s (tentative %s): failed to validate", tx.GetType(), tx.GetHash(), result.MetaData.TransactionResult)
+	if failed > 0 {
+		command.Check(fmt.Errorf("%d of %d transactions did not succeed", failed, len(all)))
+	}
+	return nil
+}
+
+// submitChain submits one account's transactions, in order, then
+// waits for their final results.  After a hard failure, later
+// transactions are not submitted.  Each transaction is journaled
+// before it is submitted, and as its status changes.  When the
+// context is done, submitChain stops submitting and waiting, and
+// reports pending transactions as such.
+func submitChain(ctx context.Context, sub *util.Subscription, j *journal.Journal, chain []data.Transaction) []*submitReport {
+	type pending struct {
+		t      data.Transaction
+		report *submitReport
+		entry  *journal.Entry
+	}
+	var waiting []pending
+
+	var reports []*submitReport
+	stopped := ""
+	for _, t := range chain {
+		report := &submitReport{
+			Type:     t.GetType(),
+			Account:  t.GetBase().Account.String(),
+			Sequence: t.GetBase().Sequence,
+		}
+		if hash := t.GetHash(); hash != nil {
+			report.Hash = hash.String()
+		}
+		reports = append(reports, report)
+
+		if stopped == "" && ctx.Err() != nil {
+			stopped = ctx.Err().Error()
+		}
+		if stopped != "" {
+			report.Result = "not submitted, " + stopped
+			continue
+		}
+
+		_, ledger, err := sub.Ledgers()
+		var entry *journal.Entry
+		var exists bool
+		if err == nil {
+			entry, exists, err = j.Record(t, ledger)
+		}
+		if err == nil && exists && entry.Final() {
+			// Already final, i.e. submit run again after a crash.
+			report.Result, report.Ledger = entry.Result, entry.Ledger
+			report.Validated = entry.Status == journal.Validated
+			if report.Validated {
+				report.Fee, _ = util.Drops(data.Amount{Value: &t.GetBase().Fee})
 			} else {
-				// Show result of validated transaction.
-				msg := fmt.Sprintf("%s %s (%s/%d) %s in ledger %d.\n", tx.GetType(), tx.GetHash(), tx.GetBase().Account, tx.GetBase().Sequence, result.MetaData.TransactionResult, result.LedgerSequence)
-				if result.MetaData.TransactionResult.Success() {
-					command.Info(msg)
-				} else {
-					command.Error(msg)
-				}
-				fmt.Println(msg) // stdout
+				stopped = fmt.Sprintf("sequence %d %s", report.Sequence, entry.Status)
 			}
+			command.Infof("%s %s (%s/%d) already %s %s, according to journal", report.Type, report.Hash, report.Account, report.Sequence, entry.Status, entry.Result)
+			continue
+		}
 
-			return err
-		})
+		var tentative *websockets.SubmitResult
+		if err == nil {
+			tentative, err = submitRetry(ctx, sub, t)
+		}
+		if err != nil {
+			if exists && entry.Status == journal.Submitted {
+				// Submitted before, so may yet be validated.
+				command.V(1).Infof("%s %s (%s/%d): %s, awaiting earlier submission", report.Type, report.Hash, report.Account, report.Sequence, err)
+				waiting = append(waiting, pending{t, report, entry})
+				continue
+			}
+			report.Result = err.Error()
+			stopped = fmt.Sprintf("sequence %d failed", report.Sequence)
+			command.Error(fmt.Sprintf("%s %s (%s/%d): %s", report.Type, report.Hash, report.Account, report.Sequence, err))
+			continue
+		}
+		report.Tentative = tentative.EngineResult.String()
+
+		if hardFailure(tentative.EngineResult) {
+			report.Result = report.Tentative
+			entry.Result = report.Tentative
+			journalUpdate(j, entry, journal.Rejected, tentative.EngineResultMessage)
+			stopped = fmt.Sprintf("sequence %d failed (%s)", report.Sequence, report.Tentative)
+			command.Error(fmt.Sprintf("%s %s (%s/%d) %s %s", report.Type, report.Hash, report.Account, report.Sequence, tentative.EngineResult, tentative.EngineResultMessage))
+			continue
+		}
+		journalUpdate(j, entry, journal.Submitted, report.Tentative)
+		waiting = append(waiting, pending{t, report, entry})
 	}
 
-	// Wait for result of each submitted transaction.
-	err = g.Wait()
-	command.Check(err)
+	for _, p := range waiting {
+		result, err := sub.WaitContext(ctx, p.t, p.entry.FirstLedger)
+		report := p.report
+		if errors.Is(err, util.ErrExpired) {
+			report.Result = "expired"
+			if result != nil {
+				report.Result = fmt.Sprintf("expired (tentative %s)", result.MetaData.TransactionResult)
+			}
+			journalUpdate(j, p.entry, journal.Expired, report.Result)
+			command.Error(fmt.Sprintf("%s %s (%s/%d) failed to validate", report.Type, report.Hash, report.Account, report.Sequence))
+			continue
+		}
+		if err != nil {
+			// Outcome unknown, entry remains pending in journal.
+			report.Result = "pending, " + err.Error()
+			command.Error(fmt.Sprintf("%s %s (%s/%d): %s", report.Type, report.Hash, report.Account, report.Sequence, err))
+			continue
+		}
 
-	return nil
+		// Show result of validated transaction.
+		report.Validated = true
+		report.Result = result.MetaData.TransactionResult.String()
+		report.Ledger = result.LedgerSequence
+		report.Fee, _ = util.Drops(data.Amount{Value: &result.Transaction.GetBase().Fee})
+		p.entry.Result, p.entry.Ledger = report.Result, report.Ledger
+		journalUpdate(j, p.entry, journal.Validated, "")
+		msg := fmt.Sprintf("%s %s (%s/%d) %s in ledger %d.", report.Type, report.Hash, report.Account, report.Sequence, result.MetaData.TransactionResult, result.LedgerSequence)
+		if result.MetaData.TransactionResult.Success() {
+			command.Info(msg)
+		} else {
+			command.Error(msg)
+		}
+	}
+	return reports
+}
+
+// journalUpdate records a status transition.  Failure is reported,
+// but does not change the outcome of the transaction.
+func journalUpdate(j *journal.Journal, entry *journal.Entry, status, detail string) {
+	err := j.Update(entry, status, detail)
+	if err != nil {
+		command.Error(fmt.Sprintf("failed to journal %s %s: %s", entry.Hash, status, err))
+	}
+}
+
+// submitRetry submits a transaction, and resubmits after each
+// validated ledger while the tentative result is retriable.  Submit
+// fails once LastLedgerSequence has passed, or the context is done.
+func submitRetry(ctx context.Context, sub *util.Subscription, t data.Transaction) (*websockets.SubmitResult, error) {
+	for {
+		tentative, err := sub.Submit(t)
+		if err != nil || !retriable(tentative.EngineResult) {
+			return tentative, err
+		}
+		_, max, err := sub.Ledgers()
+		if err != nil {
+			return tentative, err
+		}
+		command.V(1).Infof("%s (%s/%d) %s, resubmitting after ledger %d", t.GetHash(), t.GetBase().Account, t.GetBase().Sequence, tentative.EngineResult, max+1)
+		select {
+		case <-sub.AfterSequence(max + 1):
+		case <-ctx.Done():
+			return tentative, ctx.Err()
+		}
+	}
+}
+
+// retriable results may succeed if submitted again later.
+func retriable(result data.TransactionResult) bool {
+	r := result.String()
+	return r != "terQUEUED" && (strings.HasPrefix(r, "ter") || strings.HasPrefix(r, "tel"))
+}
+
+// hardFailure results cannot be included in a ledger.  Except that
+// tefPAST_SEQ and tefALREADY may indicate the transaction itself was
+// already included, so its final result is awaited.
+func hardFailure(result data.TransactionResult) bool {
+	r := result.String()
+	switch {
+	case r == "tefPAST_SEQ", r == "tefALREADY":
+		return false
+	case strings.HasPrefix(r, "tem"), strings.HasPrefix(r, "tef"):
+		return true
+	}
+	return false
 }
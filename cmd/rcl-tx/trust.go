@@ -69,7 +69,7 @@ func opTrust() error {
 
 	amount, err := cmd.AmountFromArg(argument[0])
 	if err != nil {
-		command.Errorf("bad amount (%q): %w", argument[0], err)
+		command.Errorf("bad amount (%q): %s", argument[0], err)
 		fail = true
 	} else if amount.IsNative() {
 		command.Errorf("bad amount (%q): cannot set trust for XRP", amount)
@@ -143,7 +143,7 @@ func opTrust() error {
 		tx.SetAddress(asAccount),
 		tx.SetSequence(*accountInfo.AccountData.Sequence),
 		tx.SetLastLedgerSequence(accountInfo.LedgerSequence+LedgerSequenceInterval),
-		tx.SetFee(12),
+		tx.SetFee(txFee()),
 		tx.SetLimitAmount(*amount),
 		// TODO flags
 		// TODO qualityin, qualityout
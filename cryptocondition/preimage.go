@@ -0,0 +1,175 @@
+// Copyright (C) 2020  David N. Cohen
+// This file is part of github.com/dncohen/rcl
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Cryptocondition package
+//
+// Generates and encodes PREIMAGE-SHA-256 crypto-conditions, the type
+// of condition supported by escrows on the Ripple Consensus Ledger.
+// See https://tools.ietf.org/html/draft-thomas-crypto-conditions-04.
+//
+// A condition holds the SHA-256 hash of a secret preimage.  The
+// fulfillment holds the preimage itself.  Both are DER encoded.
+//
+package cryptocondition
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+)
+
+// PreimageSize is the size of preimages generated by NewPreimage.
+const PreimageSize = 32
+
+// MaxPreimageSize is the size limit rippled imposes on fulfillments
+// (256 bytes), less the DER header.
+const MaxPreimageSize = 252
+
+const (
+	tagPreimageSha256 = 0xa0 // [0] constructed, type of condition or fulfillment
+	tagPreimage       = 0x80 // [0] primitive, fulfillment preimage
+	tagFingerprint    = 0x80 // [0] primitive, condition hash
+	tagCost           = 0x81 // [1] primitive, condition cost
+)
+
+var (
+	ErrMismatch = errors.New("fulfillment does not match condition")
+)
+
+// Preimage is the secret of a PREIMAGE-SHA-256 condition.
+type Preimage []byte
+
+// NewPreimage generates a random preimage.
+func NewPreimage() (Preimage, error) {
+	p := make(Preimage, PreimageSize)
+	_, err := rand.Read(p)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Fulfillment returns the DER encoded fulfillment.
+func (p Preimage) Fulfillment() []byte {
+	return encode(tagPreimageSha256, encode(tagPreimage, p))
+}
+
+// Condition returns the DER encoded condition, which includes the
+// hash, but not the preimage.
+func (p Preimage) Condition() []byte {
+	hash := sha256.Sum256(p)
+	body := encode(tagFingerprint, hash[:])
+	body = append(body, encode(tagCost, encodeUint(uint64(len(p))))...)
+	return encode(tagPreimageSha256, body)
+}
+
+// ParseFulfillment decodes the preimage from a DER encoded
+// fulfillment.
+func ParseFulfillment(fulfillment []byte) (Preimage, error) {
+	body, rest, err := decode(tagPreimageSha256, fulfillment)
+	if err != nil {
+		return nil, fmt.Errorf("bad fulfillment: %w", err)
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("bad fulfillment: trailing data")
+	}
+	preimage, rest, err := decode(tagPreimage, body)
+	if err != nil {
+		return nil, fmt.Errorf("bad fulfillment preimage: %w", err)
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("bad fulfillment: trailing data after preimage")
+	}
+	if len(preimage) > MaxPreimageSize {
+		return nil, fmt.Errorf("bad fulfillment: preimage size %d exceeds %d", len(preimage), MaxPreimageSize)
+	}
+	return Preimage(preimage), nil
+}
+
+// Verify returns nil if the fulfillment satisfies the condition.
+func Verify(condition, fulfillment []byte) error {
+	p, err := ParseFulfillment(fulfillment)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(p.Condition(), condition) {
+		return ErrMismatch
+	}
+	return nil
+}
+
+// encode DER tag, length and value
+func encode(tag byte, value []byte) []byte {
+	b := []byte{tag}
+	n := len(value)
+	switch {
+	case n < 0x80:
+		b = append(b, byte(n))
+	case n <= 0xff:
+		b = append(b, 0x81, byte(n))
+	default:
+		b = append(b, 0x82, byte(n>>8), byte(n))
+	}
+	return append(b, value...)
+}
+
+// decode expects the given tag, and returns its value and what follows.
+func decode(tag byte, b []byte) (value, rest []byte, err error) {
+	if len(b) < 2 {
+		return nil, nil, errors.New("truncated")
+	}
+	if b[0] != tag {
+		return nil, nil, fmt.Errorf("expected tag %#x, got %#x", tag, b[0])
+	}
+	n := int(b[1])
+	b = b[2:]
+	if n >= 0x80 {
+		size := n & 0x7f
+		if size == 0 || size > 2 || len(b) < size {
+			return nil, nil, errors.New("unsupported length")
+		}
+		n = 0
+		for _, c := range b[:size] {
+			n = n<<8 | int(c)
+		}
+		if n < 0x80 || (size == 2 && n <= 0xff) {
+			return nil, nil, errors.New("non-minimal length")
+		}
+		b = b[size:]
+	}
+	if len(b) < n {
+		return nil, nil, errors.New("truncated")
+	}
+	return b[:n], b[n:], nil
+}
+
+// encodeUint as unsigned DER integer, minimal length
+func encodeUint(i uint64) []byte {
+	var b []byte
+	for {
+		b = append([]byte{byte(i)}, b...)
+		i >>= 8
+		if i == 0 {
+			break
+		}
+	}
+	if b[0]&0x80 != 0 {
+		b = append([]byte{0}, b...) // keep positive
+	}
+	return b
+}
@@ -0,0 +1,55 @@
+package cryptocondition
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestEmptyPreimage(t *testing.T) {
+	// example from the crypto-conditions spec, and XRP Ledger docs
+	p := Preimage{}
+	if got := strings.ToUpper(hex.EncodeToString(p.Fulfillment())); got != "A0028000" {
+		t.Errorf("fulfillment %s", got)
+	}
+	expected := "A0258020E3B0C44298FC1C149AFBF4C8996FB92427AE41E4649B934CA495991B7852B855810100"
+	if got := strings.ToUpper(hex.EncodeToString(p.Condition())); got != expected {
+		t.Errorf("condition %s, expected %s", got, expected)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	for _, size := range []int{PreimageSize, 200, MaxPreimageSize} {
+		p := make(Preimage, size)
+		for i := range p {
+			p[i] = byte(i)
+		}
+		f := p.Fulfillment()
+		parsed, err := ParseFulfillment(f)
+		if err != nil {
+			t.Fatalf("size %d: %s", size, err)
+		}
+		if !bytes.Equal(parsed, p) {
+			t.Errorf("size %d: preimage mismatch", size)
+		}
+		if err := Verify(p.Condition(), f); err != nil {
+			t.Errorf("size %d: %s", size, err)
+		}
+	}
+
+	p, err := NewPreimage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewPreimage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Verify(other.Condition(), p.Fulfillment()); err != ErrMismatch {
+		t.Errorf("expected mismatch, got %v", err)
+	}
+	if _, err := ParseFulfillment(p.Condition()); err == nil {
+		t.Error("condition parsed as fulfillment")
+	}
+}
@@ -58,7 +58,9 @@
 //     rcl-key generate -nickname hot
 //
 // rcl-key generates a new keypair, and writes both address and secret
-// to a file.
+// to a file.  Each time, rcl-key prompts for a passphrase, used to
+// encrypt the secret in the `.rcl-key` file.  Operations that need the
+// secret, such as `rcl-key sign`, prompt for the passphrase again.
 //
 // The generated *address* (with nickname `hot`) does not become an
 // *account* on the test net until it is funded with enough XRP to
@@ -130,6 +132,16 @@
 //     # Replace with wss://s.altnet.rippletest.net:51233, for the TEST NET
 //     rippled=wss://s1.ripple.com:51233
 //
+//     # Most, in drops, rcl-tx will pay in transaction fees (default 10000).
+//     #maxfee=10000
+//
+//     # Where rcl-tx submit journals transactions (default $HOME/.config/rcl/journal).
+//     #journal=/var/lib/rcl/journal
+//
+//     # Noted in envelopes of composed transactions (see rcl-tx -envelope).
+//     #network=mainnet
+//     #composer=treasury-ops
+//
 //     # This creates a nickname, `bitstamp-usd` for the Bitstamp issuing address.
 //     # optional tag will be used when sending to this address, replace the example below wih your own!
 //     [bitstamp-usd]
@@ -152,6 +164,10 @@
 // 	     #tag=99999999
 //
 //     # Add nicknames for your own accounts...
+//     [treasury]
+// 	     address=rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh
+// 	     # Optional regular key (address or nickname), used by `rcl-key sign` when its .rcl-key file is present.
+// 	     #regularkey=treasury-hot
 //
 package rcl
 
@@ -8,7 +8,7 @@ require (
 	github.com/fatih/color v1.9.0 // indirect
 	github.com/go-ini/ini v1.62.0
 	github.com/golang/glog v0.0.0-20160126235308-23def4e6c14b
-	github.com/gorilla/websocket v1.4.1 // indirect
+	github.com/gorilla/websocket v1.4.1
 	github.com/json-iterator/go v1.1.9
 	github.com/juju/loggo v0.0.0-20190526231331-6e530bcce5d8 // indirect
 	github.com/juju/testing v0.0.0-20191001232224-ce9dec17d28b // indirect
@@ -0,0 +1,183 @@
+// Copyright (C) 2020  David N. Cohen
+// This file is part of github.com/dncohen/rcl
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package audit keeps a tamper-evident log of what rcl-key signs.
+//
+// The log is a file of JSON lines, only ever appended to.  Each entry
+// includes the hash of the entry before it, and its own hash.  So an
+// entry edited, removed or inserted breaks the chain, which Verify
+// detects.  Entries removed from the end of the log cannot be
+// detected this way; compare the last hash with one noted elsewhere.
+package audit
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// Entry records one decision to sign, or not.
+type Entry struct {
+	Sequence    uint64    `json:"seq"`
+	Time        time.Time `json:"time"`
+	Signer      string    `json:"signer"`
+	Account     string    `json:"account"`
+	Type        string    `json:"type"`
+	TxHash      string    `json:"tx_hash,omitempty"` // when signed
+	Amount      string    `json:"amount,omitempty"`
+	SendMax     string    `json:"send_max,omitempty"`
+	Destination string    `json:"destination,omitempty"`
+	Decision    string    `json:"decision"`         // "signed", or why not
+	Policy      string    `json:"policy,omitempty"` // "allowed", or why forbidden
+	Prev        string    `json:"prev"`             // hash of previous entry, empty for first
+	Hash        string    `json:"hash"`
+}
+
+// computeHash covers every field but Hash itself.
+func (entry Entry) computeHash() (string, error) {
+	entry.Hash = ""
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Log appends entries to a file.
+type Log struct {
+	filename string
+	last     *Entry
+}
+
+// Open prepares to append to a log file, creating it if needed.
+func Open(filename string) (*Log, error) {
+	log := &Log{filename: filename}
+	f, err := os.Open(filename)
+	if os.IsNotExist(err) {
+		return log, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	err = scan(f, func(line int, entry *Entry) error {
+		log.last = entry
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("audit log %q: %w", filename, err)
+	}
+	return log, nil
+}
+
+func (log *Log) String() string {
+	return log.filename
+}
+
+// Append chains an entry to the last, and writes it.  The file is
+// synced before Append returns.
+func (log *Log) Append(entry Entry) error {
+	entry.Sequence = 1
+	entry.Prev = ""
+	if log.last != nil {
+		entry.Sequence = log.last.Sequence + 1
+		entry.Prev = log.last.Hash
+	}
+	var err error
+	entry.Hash, err = entry.computeHash()
+	if err != nil {
+		return err
+	}
+	b, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(log.filename, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(b, '\n'))
+	if err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to append to audit log %q: %w", log.filename, err)
+	}
+	log.last = &entry
+	return nil
+}
+
+// Verify reads a log, and checks that each entry's hash is correct,
+// and follows the entry before it.  It returns the number of entries,
+// and the last.  The error, if any, identifies the first line where
+// the chain is broken.
+func Verify(r io.Reader) (count int, last *Entry, err error) {
+	err = scan(r, func(line int, entry *Entry) error {
+		hash, err := entry.computeHash()
+		if err != nil {
+			return err
+		}
+		if hash != entry.Hash {
+			return fmt.Errorf("line %d: entry %d has been altered (hash %s, expected %s)", line, entry.Sequence, entry.Hash, hash)
+		}
+		if last == nil {
+			if entry.Sequence != 1 || entry.Prev != "" {
+				return fmt.Errorf("line %d: log does not begin with its first entry (found %d)", line, entry.Sequence)
+			}
+		} else {
+			if entry.Sequence != last.Sequence+1 {
+				return fmt.Errorf("line %d: entry %d follows entry %d, expected %d", line, entry.Sequence, last.Sequence, last.Sequence+1)
+			}
+			if entry.Prev != last.Hash {
+				return fmt.Errorf("line %d: entry %d does not follow entry %d (previous hash %s, expected %s)", line, entry.Sequence, last.Sequence, entry.Prev, last.Hash)
+			}
+		}
+		count++
+		last = entry
+		return nil
+	})
+	return count, last, err
+}
+
+// scan decodes each line of a log.
+func scan(r io.Reader, f func(line int, entry *Entry) error) error {
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		entry := &Entry{}
+		err := json.Unmarshal(scanner.Bytes(), entry)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		err = f(line, entry)
+		if err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,67 @@
+package audit
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerify(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "rcl-key.audit")
+	for i, decision := range []string{"signed", "not confirmed", "signed"} {
+		// reopen each time, as separate runs of rcl-key would
+		log, err := Open(filename)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = log.Append(Entry{
+			Time:     time.Date(2020, 1, 1, 0, i, 0, 0, time.UTC),
+			Signer:   "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh",
+			Type:     "Payment",
+			Amount:   "100/XRP",
+			Decision: decision,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	count, last, err := Verify(bytes.NewReader(b))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 3 || last.Sequence != 3 {
+		t.Errorf("expected 3 entries, got %d (last %d)", count, last.Sequence)
+	}
+
+	lines := strings.SplitAfter(string(b), "\n")
+	tests := []struct {
+		name string
+		log  string
+	}{
+		{"edited", strings.Replace(string(b), "100/XRP", "1/XRP", 1)},
+		{"removed", lines[0] + lines[2]},
+		{"reordered", lines[1] + lines[0] + lines[2]},
+		{"truncated", lines[1] + lines[2]},
+	}
+	for _, test := range tests {
+		_, _, err := Verify(strings.NewReader(test.log))
+		if err == nil {
+			t.Errorf("%s log passed verification", test.name)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/dncohen/rcl/tx"
+	"github.com/dncohen/rcl/util"
+	"github.com/rubblelabs/ripple/data"
+)
+
+// FormatAmount shows an amount, with its issuer named, i.e. "100
+// USD.bitstamp" or "12 XRP".
+func FormatAmount(amount data.Amount, name func(data.Account) string) string {
+	if amount.IsNative() {
+		return fmt.Sprintf("%s XRP", util.FormatValue(*amount.Value))
+	}
+	return fmt.Sprintf("%s %s.%s", util.FormatValue(*amount.Value), amount.Currency, name(amount.Issuer))
+}
+
+// DescribeTransaction summarizes a transaction in plain language, for
+// review before signing.  For example, "Payment of 100 USD.bitstamp
+// from fund to hot, tag 12, fee 0.000012 XRP, expires ledger N".  The
+// name function shows accounts, i.e. by nickname.
+func DescribeTransaction(t data.Transaction, name func(data.Account) string) string {
+	base := t.GetBase()
+	amount := func(a data.Amount) string { return FormatAmount(a, name) }
+
+	var desc string
+	switch t := t.(type) {
+	case *data.Payment:
+		desc = fmt.Sprintf("Payment of %s from %s to %s", amount(t.Amount), name(base.Account), name(t.Destination))
+		if t.DestinationTag != nil {
+			desc += fmt.Sprintf(", tag %d", *t.DestinationTag)
+		}
+		if t.SendMax != nil {
+			desc += fmt.Sprintf(", spending at most %s", amount(*t.SendMax))
+		}
+		if t.DeliverMin != nil {
+			desc += fmt.Sprintf(", delivering at least %s", amount(*t.DeliverMin))
+		}
+	case *data.OfferCreate:
+		desc = fmt.Sprintf("Offer by %s of %s for %s", name(base.Account), amount(t.TakerGets), amount(t.TakerPays))
+		if t.OfferSequence != nil {
+			desc += fmt.Sprintf(", replacing offer %d", *t.OfferSequence)
+		}
+	case *data.OfferCancel:
+		desc = fmt.Sprintf("Cancel offer %d of %s", t.OfferSequence, name(base.Account))
+	case *data.TrustSet:
+		desc = fmt.Sprintf("Trust line of %s, limit %s", name(base.Account), amount(t.LimitAmount))
+	case *data.AccountSet:
+		desc = fmt.Sprintf("AccountSet of %s", name(base.Account))
+		if t.SetFlag != nil {
+			desc += fmt.Sprintf(", set %s", accountSetFlagName(*t.SetFlag))
+		}
+		if t.ClearFlag != nil {
+			desc += fmt.Sprintf(", clear %s", accountSetFlagName(*t.ClearFlag))
+		}
+	case *data.SetRegularKey:
+		if t.RegularKey == nil {
+			desc = fmt.Sprintf("Remove regular key of %s", name(base.Account))
+		} else {
+			desc = fmt.Sprintf("Regular key of %s set to %s", name(base.Account), name(data.Account(*t.RegularKey)))
+		}
+	case *data.SignerListSet:
+		signers := make([]string, len(t.SignerEntries))
+		for i, entry := range t.SignerEntries {
+			if entry.Account == nil || entry.SignerWeight == nil {
+				signers[i] = fmt.Sprintf("BAD ENTRY #%d", i+1)
+				continue
+			}
+			signers[i] = fmt.Sprintf("%s (weight %d)", name(*entry.Account), *entry.SignerWeight)
+		}
+		desc = fmt.Sprintf("Signer list of %s, quorum %d: %s", name(base.Account), t.SignerQuorum, strings.Join(signers, ", "))
+	case *tx.EscrowCreate:
+		desc = fmt.Sprintf("Escrow of %s from %s to %s", amount(t.Amount), name(base.Account), name(t.Destination))
+	case *data.CheckCreate:
+		desc = fmt.Sprintf("Check for up to %s from %s to %s", amount(t.SendMax), name(base.Account), name(t.Destination))
+	case *data.PaymentChannelCreate:
+		desc = fmt.Sprintf("Payment channel of %s from %s to %s", amount(t.Amount), name(base.Account), name(t.Destination))
+	default:
+		desc = fmt.Sprintf("%s by %s", t.GetType(), name(base.Account))
+	}
+
+	desc += fmt.Sprintf(", sequence %d, fee %s XRP", base.Sequence, util.FormatValue(base.Fee))
+	if base.LastLedgerSequence != nil {
+		desc += fmt.Sprintf(", expires ledger %d", *base.LastLedgerSequence)
+	}
+	return desc
+}
+
+func accountSetFlagName(flag uint32) string {
+	for name, f := range tx.AccountSetFlag {
+		if f == flag {
+			return name
+		}
+	}
+	return fmt.Sprintf("flag %d", flag)
+}
+
+// TransactionWarnings lists risky properties of a transaction, for a
+// reviewer to consider before signing.  A fee is high when it exceeds
+// `maxfee` in configuration.
+func TransactionWarnings(t data.Transaction) []string {
+	base := t.GetBase()
+	var warning []string
+
+	if base.LastLedgerSequence == nil {
+		warning = append(warning, "no LastLedgerSequence, the transaction may be validated long after it is submitted")
+	}
+	if base.Flags == nil || *base.Flags&data.TxCanonicalSignature == 0 {
+		warning = append(warning, "tfFullyCanonicalSig flag not set")
+	}
+	max, _ := MaxFee() // default when not configured
+	drops, err := util.Drops(data.Amount{Value: &base.Fee})
+	if err != nil || drops > uint64(max) {
+		warning = append(warning, fmt.Sprintf("high fee %s XRP, more than maxfee (%d drops)", util.FormatValue(base.Fee), max))
+	}
+
+	switch t := t.(type) {
+	case *data.Payment:
+		if base.Flags != nil && *base.Flags&data.TxPartialPayment != 0 {
+			warning = append(warning, "partial payment (tfPartialPayment), may deliver much less than the amount")
+		}
+	case *data.AccountSet:
+		if t.SetFlag != nil {
+			if w, ok := tx.AccountSetWarning[*t.SetFlag]; ok {
+				warning = append(warning, w)
+			}
+		}
+	case *data.SetRegularKey:
+		warning = append(warning, "changes the key which may sign for the account")
+	case *data.SignerListSet:
+		warning = append(warning, "changes the signers who may sign for the account")
+		for i, entry := range t.SignerEntries {
+			if entry.Account == nil || entry.SignerWeight == nil {
+				warning = append(warning, fmt.Sprintf("signer entry #%d lacks an account or weight", i+1))
+			}
+		}
+	}
+	return warning
+}
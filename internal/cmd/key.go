@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dncohen/rcl/internal/keyfile"
+	"github.com/dncohen/rcl/util"
+	"src.d10.dev/command"
+)
+
+// passphrases entered during this run, tried before prompting again
+var passphrases [][]byte
+
+// LoadKey reads a key file.  When the secret is encrypted, the user
+// is prompted for the passphrase.
+func LoadKey(filename string) (*keyfile.Key, error) {
+	k, err := keyfile.Read(filename)
+	if err != nil {
+		return nil, err
+	}
+	if !k.IsEncrypted() {
+		return k, nil
+	}
+
+	// often, many key files share a passphrase
+	for _, p := range passphrases {
+		if k.Decrypt(p) == nil {
+			return k, nil
+		}
+	}
+
+	for attempt := 0; attempt < 3; attempt++ {
+		p, err := ReadPassword(fmt.Sprintf("Passphrase for %s: ", filename))
+		if err != nil {
+			return nil, err
+		}
+		err = k.Decrypt(p)
+		if err == nil {
+			passphrases = append(passphrases, p)
+			return k, nil
+		}
+		if !errors.Is(err, keyfile.ErrPassphrase) {
+			return nil, fmt.Errorf("failed to decrypt %q: %w", filename, err)
+		}
+		command.Error(err)
+	}
+	return nil, fmt.Errorf("failed to decrypt %q: %w", filename, keyfile.ErrPassphrase)
+}
+
+// Keypair derives signing keys, using the algorithm recorded in the
+// key file.
+func Keypair(k *keyfile.Key) (util.Keypair, error) {
+	if k.Type == "" {
+		// file written before key type was recorded, detect from secret
+		return util.NewKeypairFromSecret(k.Secret)
+	}
+	keyType, err := util.ParseKeyType(k.Type)
+	if err != nil {
+		return util.Keypair{}, err
+	}
+	return util.NewKeypair(k.Secret, keyType)
+}
@@ -17,9 +17,12 @@
 package cmd
 
 import (
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"log"
+	"strconv"
+	"strings"
 
 	"github.com/go-ini/ini"
 	"github.com/rubblelabs/ripple/data"
@@ -151,7 +154,8 @@ func AccountConfig(acct data.Account, tag *uint32) (*ini.Section, bool) {
 
 // Helper for operations that expect a list of accounts.  We want to
 // accept (and display) accounts by local nickname, as well as normal
-// ripple address.
+// ripple address.  Either may be followed by a destination tag, i.e.
+// "<address>.<tag>", as AccountTag.String() displays them.
 func ParseAccountArg(arg []string) ([]AccountTag, error) {
 	err := initializeNicknames()
 	if err != nil {
@@ -163,14 +167,86 @@ func ParseAccountArg(arg []string) ([]AccountTag, error) {
 	for _, a := range arg {
 		acct, ok := accountByNickname[a]
 		if !ok {
-			tmp, err := data.NewAccountFromAddress(a)
+			acct, err = parseAddressTag(a)
 			if err != nil {
-				return account, fmt.Errorf("bad address (%q): %w", a, err)
+				return account, err
 			}
-			acct = NewAccountTag(*tmp, nil)
 		}
 		account = append(account, acct)
 	}
 
 	return account, err
 }
+
+// parseAddressTag parses an address or nickname, with optional
+// ".<tag>" suffix.
+func parseAddressTag(a string) (AccountTag, error) {
+	tmp, err := data.NewAccountFromAddress(a)
+	if err == nil {
+		return NewAccountTag(*tmp, nil), nil
+	}
+	if i := strings.LastIndex(a, "."); i > 0 {
+		tag, tagErr := strconv.ParseUint(a[i+1:], 10, 32)
+		if tagErr == nil {
+			acct, ok := accountByNickname[a[:i]]
+			if !ok {
+				tmp, err = data.NewAccountFromAddress(a[:i])
+				if err != nil {
+					return AccountTag{}, fmt.Errorf("bad address (%q): %w", a, err)
+				}
+				acct = NewAccountTag(*tmp, nil)
+			}
+			acct.Tag = uint32(tag)
+			return acct, nil
+		}
+	}
+	return AccountTag{}, fmt.Errorf("bad address (%q): %w", a, err)
+}
+
+// RegularKey returns the address of an account's regular key, if
+// configured.  In the account's section, `regularkey=` may be an
+// address or nickname.  Returns nil when not configured.
+func RegularKey(acct data.Account) (*data.Account, error) {
+	err := initializeNicknames()
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, ok := AccountConfig(acct, nil)
+	if !ok || !cfg.HasKey("regularkey") {
+		return nil, nil
+	}
+
+	regular, err := ParseAccountArg([]string{cfg.Key("regularkey").String()})
+	if err != nil {
+		return nil, fmt.Errorf("bad regularkey for %q: %w", cfg.Name(), err)
+	}
+	return &regular[0].Account, nil
+}
+
+// PublicKeyArg parses a public key, given either in hex, or as an
+// address or nickname whose config section includes `pubkey=` (as
+// written by `rcl-key backup`).
+func PublicKeyArg(arg string) (*data.PublicKey, error) {
+	var key data.PublicKey
+	b, err := hex.DecodeString(arg)
+	if err == nil && len(b) == len(key) {
+		copy(key[:], b)
+		return &key, nil
+	}
+
+	acct, err := ParseAccountArg([]string{arg})
+	if err != nil {
+		return nil, fmt.Errorf("expected public key (hex), address or nickname, got %q", arg)
+	}
+	cfg, ok := AccountConfig(acct[0].Account, nil)
+	if !ok || !cfg.HasKey("pubkey") {
+		return nil, fmt.Errorf("no public key (pubkey=) configured for %s", FormatAccount(acct[0].Account, nil))
+	}
+	b, err = hex.DecodeString(cfg.Key("pubkey").String())
+	if err != nil || len(b) != len(key) {
+		return nil, fmt.Errorf("bad pubkey for %q", cfg.Name())
+	}
+	copy(key[:], b)
+	return &key, nil
+}
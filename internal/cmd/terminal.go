@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+
+	"github.com/dncohen/rcl/util"
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// ReadPassword prompts for a secret, without echoing it.  The
+// controlling terminal is used, so that prompting works even when
+// stdin and stdout are part of a pipeline.
+func ReadPassword(prompt string) ([]byte, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		// no controlling terminal, fall back to stdin
+		if !terminal.IsTerminal(int(syscall.Stdin)) {
+			return nil, errors.New("cannot prompt for passphrase, no terminal")
+		}
+		fmt.Fprint(os.Stderr, prompt)
+		b, err := terminal.ReadPassword(int(syscall.Stdin))
+		fmt.Fprintln(os.Stderr)
+		return b, err
+	}
+	defer tty.Close()
+
+	fmt.Fprint(tty, prompt)
+	b, err := terminal.ReadPassword(int(tty.Fd()))
+	fmt.Fprintln(tty)
+	return b, err
+}
+
+// Confirm prompts for a yes or no answer, on the controlling
+// terminal, so that it works even when stdin and stdout are part of a
+// pipeline.
+func Confirm(prompt string) (bool, error) {
+	tty, err := os.OpenFile("/dev/tty", os.O_RDWR, 0)
+	if err != nil {
+		return false, fmt.Errorf("cannot ask for confirmation, no terminal: %w", err)
+	}
+	defer tty.Close()
+
+	fmt.Fprint(tty, prompt)
+	return util.AskForConfirmationFrom(tty, tty)
+}
+
+// ReadNewPassword prompts twice for a new secret, and fails if the
+// two entries differ.
+func ReadNewPassword(prompt string) ([]byte, error) {
+	b, err := ReadPassword(prompt)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) == 0 {
+		return nil, errors.New("passphrase must not be empty")
+	}
+	again, err := ReadPassword("Repeat to confirm: ")
+	if err != nil {
+		return nil, err
+	}
+	if string(b) != string(again) {
+		return nil, errors.New("passphrases do not match")
+	}
+	return b, nil
+}
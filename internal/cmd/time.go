@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RippleEpoch is the start of ledger time, 2000-01-01 00:00 UTC.
+// Ledger timestamps count seconds since then.
+var RippleEpoch = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// timeLayouts accepted by ParseTime, in addition to relative times.
+var timeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// ParseTime parses a human date, i.e. "2021-06-30", "2021-06-30
+// 17:00" or RFC3339.  Dates without a zone are local time.  A time
+// may also be relative to now, i.e. "+36h" or "+30d".
+func ParseTime(arg string) (time.Time, error) {
+	if strings.HasPrefix(arg, "+") {
+		d, err := ParseDuration(arg[1:])
+		if err != nil {
+			return time.Time{}, fmt.Errorf("bad relative time (%q): %w", arg, err)
+		}
+		return time.Now().Add(d), nil
+	}
+	for _, layout := range timeLayouts {
+		t, err := time.ParseInLocation(layout, arg, time.Local)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("bad time (%q), expected i.e. \"2006-01-02 15:04\" or \"+24h\"", arg)
+}
+
+// ParseDuration extends time.ParseDuration with a "d" (day) unit,
+// i.e. "30d".
+func ParseDuration(arg string) (time.Duration, error) {
+	if strings.HasSuffix(arg, "d") {
+		days, err := strconv.ParseUint(strings.TrimSuffix(arg, "d"), 10, 32)
+		if err != nil {
+			return 0, err
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(arg)
+}
+
+// RippleTime converts t to seconds since the ripple epoch.
+func RippleTime(t time.Time) (uint32, error) {
+	if t.Before(RippleEpoch) {
+		return 0, fmt.Errorf("time %s is before ripple epoch", t)
+	}
+	s := t.Unix() - RippleEpoch.Unix()
+	if s > int64(^uint32(0)) {
+		return 0, fmt.Errorf("time %s is too far in the future", t)
+	}
+	return uint32(s), nil
+}
+
+// ParseRippleTime parses a human date (see ParseTime) as seconds
+// since the ripple epoch.
+func ParseRippleTime(arg string) (uint32, error) {
+	t, err := ParseTime(arg)
+	if err != nil {
+		return 0, err
+	}
+	return RippleTime(t)
+}
+
+// FromRippleTime converts seconds since the ripple epoch to time.
+func FromRippleTime(rippleTime uint32) time.Time {
+	return RippleEpoch.Add(time.Duration(rippleTime) * time.Second)
+}
+
+// FormatRippleTime shows ledger time as local time.
+func FormatRippleTime(rippleTime uint32) string {
+	return FromRippleTime(rippleTime).Local().Format("2006-01-02 15:04:05 MST")
+}
@@ -2,6 +2,10 @@ package cmd
 
 import (
 	"errors"
+	"flag"
+	"os"
+	"os/user"
+	"path/filepath"
 	"strings"
 
 	"github.com/rubblelabs/ripple/data"
@@ -41,6 +45,80 @@ func DataAPI() (string, error) {
 	return val, nil
 }
 
+// MaxFee returns the most, in drops, a composed transaction may pay
+// in fees, from `maxfee=<drops>` in configuration.
+func MaxFee() (int, error) {
+	dfault := 10000 // 0.01 XRP
+	cfg, err := command.Config()
+	if err != nil {
+		if errors.Is(err, config.ConfigNotFound) {
+			err = nil
+		}
+		return dfault, err
+	}
+	val := cfg.Section("").Key("maxfee").MustInt(dfault)
+	if val <= 0 {
+		return val, errors.New("maxfee must be a positive number of drops")
+	}
+	return val, nil
+}
+
+// Journal returns the directory where submitted transactions are
+// journaled, from `journal=<dir>` in configuration.  The default is
+// "rcl/journal" in the user's configuration directory.
+func Journal() (string, error) {
+	dfault := ""
+	if dir, err := os.UserConfigDir(); err == nil {
+		dfault = filepath.Join(dir, "rcl", "journal")
+	}
+	val := dfault
+	cfg, err := command.Config()
+	if err == nil {
+		val = cfg.Section("").Key("journal").MustString(dfault)
+	} else if !errors.Is(err, config.ConfigNotFound) {
+		return dfault, err
+	}
+	if val == "" {
+		return val, errors.New("journal directory not found in configuration file")
+	}
+	return val, nil
+}
+
+// Network returns a name for the network transactions are composed
+// for, from `network=<name>` in configuration.  The default is the
+// rippled address.
+func Network() (string, error) {
+	rippled, _ := Rippled()
+	cfg, err := command.Config()
+	if err != nil {
+		if errors.Is(err, config.ConfigNotFound) {
+			err = nil
+		}
+		return rippled, err
+	}
+	return cfg.Section("").Key("network").MustString(rippled), nil
+}
+
+// Composer identifies who composes transactions, from
+// `composer=<name>` in configuration.  The default is user@host.
+func Composer() (string, error) {
+	dfault := "unknown"
+	if u, err := user.Current(); err == nil {
+		dfault = u.Username
+	}
+	if host, err := os.Hostname(); err == nil {
+		dfault = dfault + "@" + host
+	}
+	cfg, err := command.Config()
+	if err != nil {
+		if errors.Is(err, config.ConfigNotFound) {
+			err = nil
+		}
+		return dfault, err
+	}
+	return cfg.Section("").Key("composer").MustString(dfault), nil
+}
+
 func AmountFromArg(arg string) (*data.Amount, error) {
 	amt, err := data.NewAmount(arg)
 	if err != nil {
@@ -59,3 +137,22 @@ func AmountFromArg(arg string) (*data.Amount, error) {
 	}
 	return amt, err
 }
+
+// ParseInterspersed parses flags which may appear before, between or
+// after positional arguments, i.e. `escrow create dest 10/XRP
+// -finish-after=+1d`.  The positional arguments are returned.
+func ParseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		err := fs.Parse(args)
+		if err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
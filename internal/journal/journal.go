@@ -0,0 +1,194 @@
+// Copyright (C) 2020  David N. Cohen
+// This file is part of github.com/dncohen/rcl
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package journal records transactions submitted to the ledger, so
+// their outcome is known even if the submitting process is killed.
+//
+// Each transaction is kept in a JSON file, named for its hash, with
+// the signed blob and every status transition.  An entry is recorded
+// before the transaction is first submitted, and updated as its
+// status changes, until final.
+package journal
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/dncohen/rcl/internal/atomicfile"
+	"github.com/rubblelabs/ripple/data"
+)
+
+// Status of an entry.
+const (
+	Recorded  = "recorded"  // not yet known to be submitted
+	Submitted = "submitted" // tentative result received
+	Validated = "validated" // final, in a validated ledger (result may be tec failure)
+	Rejected  = "rejected"  // final, cannot be included in a ledger
+	Expired   = "expired"   // final, LastLedgerSequence passed without inclusion
+)
+
+// Transition is a change of an entry's status.
+type Transition struct {
+	Time   time.Time `json:"time"`
+	Status string    `json:"status"`
+	Detail string    `json:"detail,omitempty"`
+}
+
+// Entry is a journaled transaction.  FirstLedger is the last
+// validated ledger before the transaction was first submitted, so
+// the transaction can be included only in later ledgers, up to
+// LastLedgerSequence.
+type Entry struct {
+	Hash               string       `json:"hash"`
+	Type               string       `json:"type"`
+	Account            string       `json:"account"`
+	Sequence           uint32       `json:"sequence"`
+	FirstLedger        uint32       `json:"first_ledger"`
+	LastLedgerSequence uint32       `json:"last_ledger_sequence"`
+	Blob               string       `json:"blob"` // signed transaction, hex
+	Status             string       `json:"status"`
+	Result             string       `json:"result,omitempty"`
+	Ledger             uint32       `json:"ledger,omitempty"`
+	History            []Transition `json:"history"`
+}
+
+// Final returns true when the entry's outcome cannot change.
+func (e *Entry) Final() bool {
+	switch e.Status {
+	case Validated, Rejected, Expired:
+		return true
+	}
+	return false
+}
+
+// Journal is a directory of entries.
+type Journal struct {
+	dir string
+}
+
+// Open uses (and if needed, creates) a journal directory.
+func Open(dir string) (*Journal, error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+	return &Journal{dir: dir}, nil
+}
+
+func (j *Journal) String() string {
+	return j.dir
+}
+
+func (j *Journal) filename(hash string) string {
+	return filepath.Join(j.dir, fmt.Sprintf("%s.json", hash))
+}
+
+// Record journals a signed transaction, before it is submitted.  If
+// already journaled (i.e. submit is run again after a crash), the
+// existing entry is returned, and exists is true.
+func (j *Journal) Record(t data.Transaction, firstLedger uint32) (entry *Entry, exists bool, err error) {
+	base := t.GetBase()
+	if base.LastLedgerSequence == nil {
+		return nil, false, fmt.Errorf("cannot journal %s transaction without LastLedgerSequence", t.GetType())
+	}
+	hash, raw, err := data.Raw(t)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to encode %s transaction: %w", t.GetType(), err)
+	}
+
+	entry, err = j.Get(hash.String())
+	if err != nil || entry != nil {
+		return entry, entry != nil, err
+	}
+
+	entry = &Entry{
+		Hash:               hash.String(),
+		Type:               t.GetType(),
+		Account:            base.Account.String(),
+		Sequence:           base.Sequence,
+		FirstLedger:        firstLedger,
+		LastLedgerSequence: *base.LastLedgerSequence,
+		Blob:               strings.ToUpper(hex.EncodeToString(raw)),
+	}
+	return entry, false, j.Update(entry, Recorded, "")
+}
+
+// Update changes an entry's status, and writes it to the journal.
+func (j *Journal) Update(entry *Entry, status, detail string) error {
+	entry.Status = status
+	entry.History = append(entry.History, Transition{
+		Time:   time.Now().UTC(),
+		Status: status,
+		Detail: detail,
+	})
+	return j.write(entry)
+}
+
+// Get returns an entry, or nil if none.
+func (j *Journal) Get(hash string) (*Entry, error) {
+	b, err := ioutil.ReadFile(j.filename(hash))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	entry := &Entry{}
+	err = json.Unmarshal(b, entry)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse journal entry %q: %w", j.filename(hash), err)
+	}
+	return entry, nil
+}
+
+// Entries returns all entries, ordered by account and sequence.
+func (j *Journal) Entries() ([]*Entry, error) {
+	names, err := filepath.Glob(filepath.Join(j.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var entries []*Entry
+	for _, name := range names {
+		entry, err := j.Get(strings.TrimSuffix(filepath.Base(name), ".json"))
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	sort.SliceStable(entries, func(a, b int) bool {
+		if entries[a].Account != entries[b].Account {
+			return entries[a].Account < entries[b].Account
+		}
+		return entries[a].Sequence < entries[b].Sequence
+	})
+	return entries, nil
+}
+
+// write replaces an entry's file.
+func (j *Journal) write(entry *Entry) error {
+	b, err := json.MarshalIndent(entry, "", "\t")
+	if err != nil {
+		return err
+	}
+	return atomicfile.Write(j.filename(entry.Hash), b, 0600)
+}
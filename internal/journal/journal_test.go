@@ -0,0 +1,66 @@
+package journal
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/dncohen/rcl/internal/txtest"
+	"github.com/rubblelabs/ripple/data"
+)
+
+const testAddress = "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh"
+
+func testTx(t *testing.T, sequence uint32) data.Transaction {
+	tx := txtest.AccountSet(t, testAddress, sequence)
+	lastLedger := uint32(1000)
+	tx.LastLedgerSequence = &lastLedger
+	return tx
+}
+
+func TestRecordUpdate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	j, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry, exists, err := j.Record(testTx(t, 2), 990)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exists || entry.Status != Recorded || entry.Blob == "" {
+		t.Errorf("unexpected new entry %+v (exists %t)", entry, exists)
+	}
+	_, _, err = j.Record(testTx(t, 1), 990)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	entry.Result, entry.Ledger = "tesSUCCESS", 995
+	err = j.Update(entry, Validated, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	again, exists, err := j.Record(testTx(t, 2), 999)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !exists || !again.Final() || again.FirstLedger != 990 || len(again.History) != 2 {
+		t.Errorf("expected existing final entry, got %+v (exists %t)", again, exists)
+	}
+
+	entries, err := j.Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Sequence != 1 || entries[1].Sequence != 2 {
+		t.Errorf("expected 2 entries in sequence order, got %+v", entries)
+	}
+}
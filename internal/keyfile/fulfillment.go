@@ -0,0 +1,71 @@
+// Copyright (C) 2020  David N. Cohen
+// This file is part of github.com/dncohen/rcl
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package keyfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/rubblelabs/ripple/data"
+)
+
+// Fulfillment is the secret of a conditional escrow.  Like a key,
+// it is saved to a file readable only by its owner.  The escrow is
+// identified by the owner and sequence of its EscrowCreate
+// transaction.
+type Fulfillment struct {
+	Owner       data.Account        `json:"owner"`
+	Sequence    uint32              `json:"sequence"`
+	Condition   data.VariableLength `json:"condition"`
+	Fulfillment data.VariableLength `json:"fulfillment"`
+}
+
+// FulfillmentFilename is the conventional name of the file holding an
+// escrow's fulfillment.
+func FulfillmentFilename(owner data.Account, sequence uint32) string {
+	return fmt.Sprintf("%s-%d.rcl-fulfillment", owner, sequence)
+}
+
+// SaveFulfillment writes a fulfillment to a new file, readable only by
+// the owner.  An existing file is not overwritten.
+func SaveFulfillment(f *Fulfillment, filename string) error {
+	file, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE|os.O_EXCL, 0400)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	enc := json.NewEncoder(file)
+	enc.SetIndent("", "\t")
+	return enc.Encode(f)
+}
+
+// ReadFulfillment decodes a fulfillment file.
+func ReadFulfillment(filename string) (*Fulfillment, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	f := &Fulfillment{}
+	err = json.Unmarshal(b, f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse fulfillment file %q: %w", filename, err)
+	}
+	return f, nil
+}
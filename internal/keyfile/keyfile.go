@@ -0,0 +1,241 @@
+// Copyright (C) 2019-2020  David N. Cohen
+// This file is part of github.com/dncohen/rcl
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Keyfile package
+//
+// Reads and writes the `.rcl-key` files produced by `rcl-key
+// generate`.  A key file holds an address, optional nickname, and
+// secret.  The secret may be encrypted with a key derived from a
+// passphrase (scrypt), using authenticated encryption
+// (XChaCha20-Poly1305).  Files written before encryption was
+// supported hold the secret in plain text, and can still be read.
+package keyfile
+
+import (
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/dncohen/rcl/internal/atomicfile"
+	"github.com/rubblelabs/ripple/data"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/scrypt"
+)
+
+const (
+	// Version of the encrypted secret format.  Increment when the
+	// format changes, so that older files can still be decrypted.
+	Version = 1
+
+	kdfScrypt     = "scrypt"
+	cipherXChaCha = "xchacha20-poly1305"
+
+	// Recommended scrypt parameters for interactive use, see
+	// https://godoc.org/golang.org/x/crypto/scrypt
+	scryptN = 32768
+	scryptR = 8
+	scryptP = 1
+
+	// upper bound on work factor, to reject unreasonable (or malicious) files
+	maxScryptN = 1 << 22
+
+	saltLen = 32
+	keyLen  = chacha20poly1305.KeySize
+)
+
+var (
+	// ErrPassphrase indicates an encrypted secret could not be
+	// decrypted, most likely because the passphrase was incorrect.
+	ErrPassphrase = errors.New("incorrect passphrase (or corrupt key file)")
+
+	ErrNotEncrypted = errors.New("key file is not encrypted")
+)
+
+// Key is primarily used to marshal keys to/from files.
+type Key struct {
+	Account  data.Account `json:"address"`
+	Secret   string       `json:"secret,omitempty"`
+	Nickname string       `json:"nickname"`
+
+	// Type is the key algorithm, "ed25519" or "secp256k1".  Files
+	// written before ed25519 was supported have no type, and are
+	// secp256k1.
+	Type string `json:"type,omitempty"`
+
+	// Encrypted holds the secret, when protected by a passphrase.  In
+	// that case, Secret is empty until Decrypt() succeeds.
+	Encrypted *EncryptedSecret `json:"encrypted,omitempty"`
+}
+
+// EncryptedSecret is the versioned header and ciphertext of a
+// passphrase-protected secret.
+type EncryptedSecret struct {
+	Version int `json:"version"`
+
+	// key derivation
+	KDF  string `json:"kdf"`
+	N    int    `json:"n"`
+	R    int    `json:"r"`
+	P    int    `json:"p"`
+	Salt []byte `json:"salt"`
+
+	// authenticated encryption
+	Cipher     string `json:"cipher"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+func (k *Key) IsEncrypted() bool {
+	return k.Encrypted != nil
+}
+
+// Encrypt replaces the plain text secret with an encrypted one.
+func (k *Key) Encrypt(passphrase []byte) error {
+	if k.IsEncrypted() {
+		return errors.New("key file is already encrypted")
+	}
+	if k.Secret == "" {
+		return errors.New("key has no secret to encrypt")
+	}
+	if len(passphrase) == 0 {
+		return errors.New("passphrase must not be empty")
+	}
+
+	enc := &EncryptedSecret{
+		Version: Version,
+		KDF:     kdfScrypt,
+		N:       scryptN,
+		R:       scryptR,
+		P:       scryptP,
+		Salt:    make([]byte, saltLen),
+		Cipher:  cipherXChaCha,
+		Nonce:   make([]byte, chacha20poly1305.NonceSizeX),
+	}
+	if _, err := rand.Read(enc.Salt); err != nil {
+		return err
+	}
+	if _, err := rand.Read(enc.Nonce); err != nil {
+		return err
+	}
+
+	aead, err := enc.aead(passphrase)
+	if err != nil {
+		return err
+	}
+	enc.Ciphertext = aead.Seal(nil, enc.Nonce, []byte(k.Secret), enc.additionalData(k.Account))
+
+	k.Encrypted = enc
+	k.Secret = ""
+	return nil
+}
+
+// Decrypt restores the plain text secret of an encrypted key.  The
+// key is not modified if decryption fails.
+func (k *Key) Decrypt(passphrase []byte) error {
+	enc := k.Encrypted
+	if enc == nil {
+		return ErrNotEncrypted
+	}
+	if enc.Version != Version {
+		return fmt.Errorf("unsupported key file version %d (expected %d)", enc.Version, Version)
+	}
+	if enc.Cipher != cipherXChaCha {
+		return fmt.Errorf("unsupported key file cipher %q", enc.Cipher)
+	}
+
+	aead, err := enc.aead(passphrase)
+	if err != nil {
+		return err
+	}
+	if len(enc.Nonce) != aead.NonceSize() {
+		return fmt.Errorf("bad key file nonce (%d bytes)", len(enc.Nonce))
+	}
+	secret, err := aead.Open(nil, enc.Nonce, enc.Ciphertext, enc.additionalData(k.Account))
+	if err != nil {
+		// do not wrap err, which reveals nothing useful
+		return ErrPassphrase
+	}
+
+	k.Secret = string(secret)
+	k.Encrypted = nil
+	return nil
+}
+
+// derive the encryption key from passphrase
+func (enc *EncryptedSecret) aead(passphrase []byte) (cipher.AEAD, error) {
+	if enc.KDF != kdfScrypt {
+		return nil, fmt.Errorf("unsupported key file kdf %q", enc.KDF)
+	}
+	if enc.N <= 1 || enc.N > maxScryptN || enc.R <= 0 || enc.P <= 0 {
+		return nil, fmt.Errorf("unsupported key file kdf parameters (N=%d, r=%d, p=%d)", enc.N, enc.R, enc.P)
+	}
+	key, err := scrypt.Key(passphrase, enc.Salt, enc.N, enc.R, enc.P, keyLen)
+	if err != nil {
+		return nil, err
+	}
+	return chacha20poly1305.NewX(key)
+}
+
+// The header fields, and address, are authenticated along with the
+// ciphertext.  So the secret cannot be moved to a file claiming a
+// different address, or decrypted with downgraded parameters.
+func (enc *EncryptedSecret) additionalData(account data.Account) []byte {
+	return []byte(fmt.Sprintf("rcl-key:%d:%s:%s:%d:%d:%d:%s",
+		enc.Version, account, enc.KDF, enc.N, enc.R, enc.P, enc.Cipher))
+}
+
+// Save writes a key to a new file, readable only by the owner.
+func Save(key *Key, filename string) error {
+	file, err := os.OpenFile(filename, os.O_RDWR|os.O_CREATE, 0400)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	enc := json.NewEncoder(file)
+	enc.SetIndent("", "\t")
+	err = enc.Encode(key)
+	return err
+}
+
+// Replace overwrites an existing key file, readable only by the
+// owner, as Save writes it.
+func Replace(key *Key, filename string) error {
+	b, err := json.MarshalIndent(key, "", "\t")
+	if err != nil {
+		return err
+	}
+	return atomicfile.Write(filename, append(b, '\n'), 0400)
+}
+
+// Read decodes a key file.  If the secret is encrypted, caller must
+// Decrypt() before using it.
+func Read(filename string) (*Key, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	key := &Key{}
+	err = json.Unmarshal(b, key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse key file %q: %w", filename, err)
+	}
+	return key, nil
+}
@@ -0,0 +1,107 @@
+package keyfile
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rubblelabs/ripple/data"
+)
+
+const (
+	testAddress = "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh"
+	testSecret  = "snoPBrXtMeMyMHUVTgbuqAfg1SUTb"
+)
+
+func testKey(t *testing.T) *Key {
+	acct, err := data.NewAccountFromAddress(testAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &Key{Account: *acct, Secret: testSecret, Nickname: "test"}
+}
+
+func TestEncryptDecrypt(t *testing.T) {
+	dir, err := ioutil.TempDir("", "keyfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	filename := filepath.Join(dir, testAddress+".rcl-key")
+
+	k := testKey(t)
+	err = k.Encrypt([]byte("correct horse"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if k.Secret != "" {
+		t.Errorf("wanted empty secret after encrypt, got %q", k.Secret)
+	}
+
+	err = Save(k, filename)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	k, err = Read(filename)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if !k.IsEncrypted() {
+		t.Error("wanted encrypted key file")
+		return
+	}
+
+	err = k.Decrypt([]byte("battery staple"))
+	if !errors.Is(err, ErrPassphrase) {
+		t.Errorf("wanted %q, got %v", ErrPassphrase, err)
+	}
+
+	err = k.Decrypt([]byte("correct horse"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if k.Secret != testSecret {
+		t.Errorf("wanted %s, got %q", testSecret, k.Secret)
+	}
+
+	// replace with plain text
+	err = Replace(k, filename)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	k, err = Read(filename)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if k.IsEncrypted() || k.Secret != testSecret {
+		t.Errorf("wanted plain text secret %s, got %q", testSecret, k.Secret)
+	}
+}
+
+// encrypted secret must not decrypt under a different address
+func TestDecryptWrongAddress(t *testing.T) {
+	k := testKey(t)
+	err := k.Encrypt([]byte("correct horse"))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+
+	other, err := data.NewAccountFromAddress("rrrrrrrrrrrrrrrrrrrrrhoLvTp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	k.Account = *other
+	err = k.Decrypt([]byte("correct horse"))
+	if !errors.Is(err, ErrPassphrase) {
+		t.Errorf("wanted %q, got %v", ErrPassphrase, err)
+	}
+}
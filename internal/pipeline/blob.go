@@ -0,0 +1,313 @@
+// Copyright (C) 2020  David N. Cohen
+// This file is part of github.com/dncohen/rcl
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pipeline
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/dncohen/rcl/util"
+	"github.com/rubblelabs/ripple/data"
+)
+
+// Serialized type codes, as in rippled's SField.
+const (
+	stUint16    = 1
+	stUint32    = 2
+	stUint64    = 3
+	stHash128   = 4
+	stHash256   = 5
+	stAmount    = 6
+	stVL        = 7
+	stAccount   = 8
+	stObject    = 14
+	stArray     = 15
+	stUint8     = 16
+	stHash160   = 17
+	stPathSet   = 18
+	stVector256 = 19
+)
+
+// Field codes.  An object, or array, ends with a field of its own
+// type and code endMarker.
+const (
+	endMarker   = 1
+	signersCode = 3  // stArray
+	signerCode  = 16 // stObject
+
+	fulfillmentCode = 16 // stVL
+	conditionCode   = 17 // stVL
+
+	accountCode       = 1 // stAccount
+	signingPubKeyCode = 3 // stVL
+	txnSignatureCode  = 4 // stVL
+)
+
+var errTruncated = errors.New("transaction blob is truncated")
+
+// field is one serialized field of a transaction.
+type field struct {
+	typ, code int
+	header    int    // length of header, in raw
+	raw       []byte // header and value
+}
+
+func (f field) value() []byte {
+	return f.raw[f.header:]
+}
+
+// readTransaction decodes a transaction blob.  data.ReadTransaction
+// fails on fields which rubblelabs' transaction types lack, so those
+// are split off, and decoded here.
+func readTransaction(raw []byte) (data.Transaction, error) {
+	fields, err := splitFields(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var rest []byte
+	var signers []util.Signer
+	var condition, fulfillment *data.VariableLength
+	for _, f := range fields {
+		switch {
+		case f.typ == stArray && f.code == signersCode:
+			signers, err = readSigners(f.value())
+			if err != nil {
+				return nil, fmt.Errorf("bad Signers: %w", err)
+			}
+		case f.typ == stVL && (f.code == conditionCode || f.code == fulfillmentCode):
+			value, err := variableLength(f.value())
+			if err != nil {
+				return nil, err
+			}
+			vl := data.VariableLength(value)
+			if f.code == conditionCode {
+				condition = &vl
+			} else {
+				fulfillment = &vl
+			}
+		default:
+			rest = append(rest, f.raw...)
+		}
+	}
+
+	t, err := data.ReadTransaction(bytes.NewReader(rest))
+	if err != nil {
+		return nil, err
+	}
+	t, err = escrow(t, condition, fulfillment)
+	if err != nil {
+		return nil, err
+	}
+	if len(signers) > 0 {
+		return &util.MultiSigned{Transaction: t, Signers: signers}, nil
+	}
+	return t, nil
+}
+
+// readSigners decodes the content of a Signers array.
+func readSigners(b []byte) ([]util.Signer, error) {
+	objects, err := splitFields(b)
+	if err != nil {
+		return nil, err
+	}
+	var signers []util.Signer
+	for _, object := range objects {
+		if object.typ == stArray && object.code == endMarker {
+			return signers, nil
+		}
+		if object.typ != stObject || object.code != signerCode {
+			return nil, fmt.Errorf("unexpected field (type %d, code %d)", object.typ, object.code)
+		}
+		fields, err := splitFields(object.value())
+		if err != nil {
+			return nil, err
+		}
+		var s util.Signer
+		for _, f := range fields {
+			var value []byte
+			if f.typ == stAccount || f.typ == stVL {
+				value, err = variableLength(f.value())
+				if err != nil {
+					return nil, err
+				}
+			}
+			switch {
+			case f.typ == stAccount && f.code == accountCode && len(value) == len(s.Signer.Account):
+				copy(s.Signer.Account[:], value)
+			case f.typ == stVL && f.code == signingPubKeyCode && len(value) == len(s.Signer.SigningPubKey):
+				copy(s.Signer.SigningPubKey[:], value)
+			case f.typ == stVL && f.code == txnSignatureCode:
+				s.Signer.TxnSignature = data.VariableLength(value)
+			case f.typ == stObject && f.code == endMarker:
+			default:
+				return nil, fmt.Errorf("unexpected field in Signer (type %d, code %d)", f.typ, f.code)
+			}
+		}
+		signers = append(signers, s)
+	}
+	return nil, errTruncated // no end of array
+}
+
+// splitFields splits serialized fields.  The end marker of an object
+// or array is itself a field.
+func splitFields(b []byte) ([]field, error) {
+	var fields []field
+	for len(b) > 0 {
+		f, err := readField(b)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, f)
+		b = b[len(f.raw):]
+	}
+	return fields, nil
+}
+
+// readField reads the field at the start of b.
+func readField(b []byte) (field, error) {
+	var f field
+	if len(b) < 1 {
+		return f, errTruncated
+	}
+	f.typ, f.code, f.header = int(b[0]>>4), int(b[0]&0x0f), 1
+	if f.typ == 0 {
+		if len(b) <= f.header {
+			return f, errTruncated
+		}
+		f.typ = int(b[f.header])
+		f.header++
+	}
+	if f.code == 0 {
+		if len(b) <= f.header {
+			return f, errTruncated
+		}
+		f.code = int(b[f.header])
+		f.header++
+	}
+
+	n, err := valueLength(f, b[f.header:])
+	if err != nil {
+		return f, err
+	}
+	if f.header+n > len(b) {
+		return f, errTruncated
+	}
+	f.raw = b[:f.header+n]
+	return f, nil
+}
+
+// valueLength returns the length of a field's serialized value.
+func valueLength(f field, b []byte) (int, error) {
+	switch f.typ {
+	case stUint8:
+		return 1, nil
+	case stUint16:
+		return 2, nil
+	case stUint32:
+		return 4, nil
+	case stUint64:
+		return 8, nil
+	case stHash128:
+		return 16, nil
+	case stHash160:
+		return 20, nil
+	case stHash256:
+		return 32, nil
+	case stAmount:
+		if len(b) < 1 {
+			return 0, errTruncated
+		}
+		if b[0]&0x80 == 0 {
+			return 8, nil // native
+		}
+		return 48, nil
+	case stVL, stAccount, stVector256:
+		n, prefix, err := lengthPrefix(b)
+		return prefix + n, err
+	case stObject, stArray:
+		if f.code == endMarker {
+			return 0, nil
+		}
+		// inner fields, through the end marker
+		n := 0
+		for {
+			inner, err := readField(b[n:])
+			if err != nil {
+				return 0, err
+			}
+			n += len(inner.raw)
+			if inner.typ == f.typ && inner.code == endMarker {
+				return n, nil
+			}
+		}
+	case stPathSet:
+		for n := 0; n < len(b); {
+			step := b[n]
+			n++
+			switch step {
+			case 0x00: // end of path set
+				return n, nil
+			case 0xff: // end of path
+				continue
+			}
+			for _, flag := range []byte{0x01, 0x10, 0x20} { // account, currency, issuer
+				if step&flag != 0 {
+					n += 20
+				}
+			}
+		}
+		return 0, errTruncated
+	}
+	return 0, fmt.Errorf("unknown field type %d", f.typ)
+}
+
+// lengthPrefix decodes the length of a variable length value, and
+// returns the length of the prefix itself.
+func lengthPrefix(b []byte) (n, prefix int, err error) {
+	if len(b) < 1 {
+		return 0, 0, errTruncated
+	}
+	switch b0 := int(b[0]); {
+	case b0 <= 192:
+		return b0, 1, nil
+	case b0 <= 240:
+		if len(b) < 2 {
+			return 0, 0, errTruncated
+		}
+		return 193 + (b0-193)*256 + int(b[1]), 2, nil
+	case b0 <= 254:
+		if len(b) < 3 {
+			return 0, 0, errTruncated
+		}
+		return 12481 + (b0-241)*65536 + int(b[1])*256 + int(b[2]), 3, nil
+	}
+	return 0, 0, fmt.Errorf("bad length prefix %x", b[0])
+}
+
+// variableLength returns the content of a variable length value.
+func variableLength(b []byte) ([]byte, error) {
+	n, prefix, err := lengthPrefix(b)
+	if err != nil {
+		return nil, err
+	}
+	if prefix+n > len(b) {
+		return nil, errTruncated
+	}
+	return b[prefix : prefix+n], nil
+}
@@ -0,0 +1,251 @@
+// Copyright (C) 2020  David N. Cohen
+// This file is part of github.com/dncohen/rcl
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package pipeline
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/dncohen/rcl/tx"
+	"github.com/dncohen/rcl/util"
+	"github.com/rubblelabs/ripple/data"
+)
+
+// EnvelopeVersion is the version of envelopes written.  Envelopes of
+// a later version cannot be decoded.
+const EnvelopeVersion = 1
+
+// Envelope carries a transaction through a pipeline, with provenance
+// and notes for those who review it before signing.  An envelope is
+// optional: transactions decoded from bare JSON or blobs have none,
+// and in blob format an envelope cannot be written.
+type Envelope struct {
+	Version     int               `json:"envelope"`
+	Composer    string            `json:"composer,omitempty"` // i.e. user@host
+	Composed    *time.Time        `json:"composed,omitempty"`
+	Network     string            `json:"network,omitempty"`
+	Description string            `json:"description,omitempty"`
+	Nicknames   map[string]string `json:"nicknames,omitempty"` // address to nickname, when composed
+	Approvals   []Approval        `json:"approvals,omitempty"`
+	Transaction data.Transaction  `json:"transaction"`
+}
+
+// Approval records a reviewer's approval of a transaction.  The
+// signature, if any, is of the transaction's ApprovalHash (see
+// SignApproval).  An approval without a valid signature, from a
+// known key, is only a claim.
+type Approval struct {
+	Account   string    `json:"account"`
+	Time      time.Time `json:"time"`
+	PublicKey string    `json:"public_key,omitempty"` // hex
+	Signature string    `json:"signature,omitempty"`  // hex
+	Note      string    `json:"note,omitempty"`
+}
+
+// UnmarshalJSON decodes the transaction into its concrete type.
+func (env *Envelope) UnmarshalJSON(b []byte) error {
+	type plain Envelope
+	tmp := struct {
+		*plain
+		Transaction json.RawMessage `json:"transaction"`
+	}{plain: (*plain)(env)}
+
+	err := json.Unmarshal(b, &tmp)
+	if err != nil {
+		return err
+	}
+	if env.Version > EnvelopeVersion {
+		return fmt.Errorf("envelope version %d is not supported (expected %d or lower)", env.Version, EnvelopeVersion)
+	}
+	if len(tmp.Transaction) == 0 {
+		return fmt.Errorf("envelope has no transaction")
+	}
+	env.Transaction, err = unmarshalTransaction(tmp.Transaction)
+	return err
+}
+
+// isEnvelope distinguishes an envelope from a bare transaction.
+func isEnvelope(b json.RawMessage) (bool, error) {
+	var fields map[string]json.RawMessage
+	err := json.Unmarshal(b, &fields)
+	if err != nil {
+		return false, err
+	}
+	_, ok := fields["envelope"]
+	return ok, nil
+}
+
+// NewEnvelope, when not nil, is called by EncodeFormat for each
+// transaction without an envelope.  Commands which compose
+// transactions may set it, to provide an envelope.
+var NewEnvelope func(tx data.Transaction) *Envelope
+
+// value returns what is written for an item, either an envelope or
+// the bare transaction.
+func (item Item) value() interface{} {
+	env := item.Envelope
+	if env == nil && NewEnvelope != nil {
+		env = NewEnvelope(item.Transaction)
+	}
+	if env == nil {
+		return item.Full()
+	}
+	env.Version = EnvelopeVersion
+	env.Transaction = item.Full()
+	return env
+}
+
+// ApprovalHash identifies a transaction's content, apart from
+// signatures.  So an approval made before a transaction is signed
+// remains valid after.
+func ApprovalHash(tx data.Transaction) (data.Hash256, error) {
+	base := tx.GetBase()
+	pubkey, signature := base.SigningPubKey, base.TxnSignature
+	base.SigningPubKey, base.TxnSignature = nil, nil
+	defer func() {
+		base.SigningPubKey, base.TxnSignature = pubkey, signature
+	}()
+
+	hash, _, err := data.Raw(tx)
+	return hash, err
+}
+
+// SignApproval approves a transaction on behalf of account, signing
+// its ApprovalHash with pair (the account's master or regular key).
+func SignApproval(tx data.Transaction, account data.Account, pair util.Keypair, note string) (*Approval, error) {
+	hash, err := ApprovalHash(tx)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := pair.SignMessage(hash[:])
+	if err != nil {
+		return nil, err
+	}
+	pubkey := pair.PublicKey()
+	return &Approval{
+		Account:   account.String(),
+		Time:      time.Now().UTC(),
+		PublicKey: fmt.Sprintf("%X", pubkey[:]),
+		Signature: fmt.Sprintf("%X", []byte(signature)),
+		Note:      note,
+	}, nil
+}
+
+// VerifyApproval checks an approval's signature of a transaction, and
+// returns the public key which signed it.  Note the key is the one
+// included in the approval; whether it may approve for the
+// approval's account is for the caller to decide.
+func VerifyApproval(tx data.Transaction, approval Approval) (*data.PublicKey, error) {
+	if approval.PublicKey == "" || approval.Signature == "" {
+		return nil, errors.New("approval is not signed")
+	}
+	var pubkey data.PublicKey
+	b, err := hex.DecodeString(approval.PublicKey)
+	if err != nil || len(b) != len(pubkey) {
+		return nil, fmt.Errorf("bad approval public key (%q)", approval.PublicKey)
+	}
+	copy(pubkey[:], b)
+	signature, err := hex.DecodeString(approval.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("bad approval signature: %w", err)
+	}
+
+	hash, err := ApprovalHash(tx)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := util.VerifyMessage(pubkey, hash[:], signature)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("approval signature does not match transaction")
+	}
+	return &pubkey, nil
+}
+
+// Accounts returns the accounts a transaction refers to: the
+// transacting account, destination if any, and issuers of amounts.
+func Accounts(t data.Transaction) []data.Account {
+	accounts := []data.Account{t.GetBase().Account}
+	var amounts []*data.Amount
+	switch t := t.(type) {
+	case *data.Payment:
+		accounts = append(accounts, t.Destination)
+		amounts = append(amounts, &t.Amount, t.SendMax, t.DeliverMin)
+	case *data.OfferCreate:
+		amounts = append(amounts, &t.TakerPays, &t.TakerGets)
+	case *data.TrustSet:
+		amounts = append(amounts, &t.LimitAmount)
+	case *tx.EscrowCreate:
+		accounts = append(accounts, t.Destination)
+	case *data.CheckCreate:
+		accounts = append(accounts, t.Destination)
+		amounts = append(amounts, &t.SendMax)
+	case *data.PaymentChannelCreate:
+		accounts = append(accounts, t.Destination)
+	}
+	for _, amount := range amounts {
+		if amount != nil && !amount.IsNative() {
+			accounts = append(accounts, amount.Issuer)
+		}
+	}
+
+	// unique
+	seen := make(map[data.Account]bool)
+	unique := accounts[:0]
+	for _, account := range accounts {
+		if !seen[account] {
+			seen[account] = true
+			unique = append(unique, account)
+		}
+	}
+	return unique
+}
+
+// MergeApprovals adds to an item's envelope the approvals in the
+// envelope of another copy of its transaction (i.e. when combining
+// multi-signed copies).  Approvals already present are not
+// duplicated.
+func MergeApprovals(item *Item, other Item) {
+	from := other.Envelope
+	if from == nil || len(from.Approvals) == 0 {
+		return
+	}
+	if item.Envelope == nil {
+		copied := *from
+		copied.Approvals = nil
+		item.Envelope = &copied
+	}
+
+	env := item.Envelope
+	for _, approval := range from.Approvals {
+		found := false
+		for _, existing := range env.Approvals {
+			if existing.Account == approval.Account && existing.Signature == approval.Signature {
+				found = true
+				break
+			}
+		}
+		if !found {
+			env.Approvals = append(env.Approvals, approval)
+		}
+	}
+}
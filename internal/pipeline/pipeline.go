@@ -19,30 +19,116 @@
 // Command that expect RCL transactions as input or output can use
 // pipeline helper function to encode and decode transactions to stdin
 // or stdout.  Or, to files.  Pipeline uses JSON as the underlying
-// encoding.
+// encoding, by default.  Alternatively, transactions are hex encoded
+// binary, one per line, as in rippled's tx_blob.  Input format is
+// detected.
+//
+// In JSON format, a transaction may be wrapped in an Envelope, with
+// notes about where it came from.  Envelopes are decoded along with
+// transactions, as an Item, and written again by EncodeItems.  So
+// operations which read, then write, items preserve them.
 package pipeline
 
 import (
+	"bufio"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"os"
+	"path/filepath"
+	"strings"
 
+	"github.com/dncohen/rcl/tx"
+	"github.com/dncohen/rcl/util"
 	"github.com/rubblelabs/ripple/data"
 )
 
+// Formats of transactions in a pipeline.
+const (
+	FormatJSON = "json" // indented JSON, as rubblelabs encodes it
+	FormatBlob = "blob" // hex, one transaction per line
+)
+
+// OutputFormat is the format written by EncodeOutput.  Commands may
+// set it, i.e. from a -format flag.
+var OutputFormat = FormatJSON
+
 var emptyHash = data.Hash256{}
 
-func DecodeInput(c chan data.Transaction, r io.Reader) error {
-	dec := json.NewDecoder(r)
+// ParseFormat validates a format name.
+func ParseFormat(format string) (string, error) {
+	switch format {
+	case FormatJSON, FormatBlob:
+		return format, nil
+	}
+	return "", fmt.Errorf("unknown format %q, expected %q or %q", format, FormatJSON, FormatBlob)
+}
 
-	for dec.More() {
+// Item is a transaction in a pipeline, with its envelope, if any.
+// The Signers of a multi-signed transaction are kept apart from it,
+// as rubblelabs' transaction types have no field for them.
+type Item struct {
+	Transaction data.Transaction
+	Signers     []util.Signer
+	Envelope    *Envelope // nil when the transaction has none
+}
 
-		// we rely on rubblelabs' ability to decode into
-		// TransactionWithMetaData (even though we don't expect metadata to
-		// actually be present).
-		tx := &data.TransactionWithMetaData{}
+// Full returns the transaction as it is encoded and submitted, that
+// is, with Signers when multi-signed.
+func (item Item) Full() data.Transaction {
+	if len(item.Signers) == 0 {
+		return item.Transaction
+	}
+	return &util.MultiSigned{Transaction: item.Transaction, Signers: item.Signers}
+}
 
-		err := dec.Decode(tx)
+// newItem makes an item of a decoded transaction, which may be
+// multi-signed.
+func newItem(t data.Transaction, env *Envelope) Item {
+	if multi, ok := t.(*util.MultiSigned); ok {
+		if env != nil {
+			env.Transaction = multi.Transaction
+		}
+		return Item{Transaction: multi.Transaction, Signers: multi.Signers, Envelope: env}
+	}
+	return Item{Transaction: t, Envelope: env}
+}
+
+// DecodeInput reads transactions, in either format, and sends each to
+// the channel.  The format is detected from the first character
+// (other than whitespace) of input.
+func DecodeInput(c chan Item, r io.Reader) error {
+	br := bufio.NewReader(r)
+	for {
+		b, err := br.ReadByte()
+		if err == io.EOF {
+			return nil // no input
+		}
+		if err != nil {
+			return err
+		}
+		if b == ' ' || b == '\t' || b == '\r' || b == '\n' {
+			continue
+		}
+		err = br.UnreadByte()
+		if err != nil {
+			return err
+		}
+		if b == '{' {
+			return decodeJSON(c, br)
+		}
+		return decodeBlob(c, br)
+	}
+}
+
+func decodeJSON(c chan Item, r io.Reader) error {
+	dec := json.NewDecoder(r)
+
+	for dec.More() {
+		var raw json.RawMessage
+		err := dec.Decode(&raw)
 		if err != nil {
 			if errors.Is(err, io.EOF) { // not reached
 				break
@@ -50,26 +136,217 @@ func DecodeInput(c chan data.Transaction, r io.Reader) error {
 			return err
 		}
 
-		// TODO(dnc): try to eliminate unwanted empty hash when tx unsigned
-		if tx.Transaction.GetBase().Hash == emptyHash {
-			//tx.Transaction.GetBase().Hash = nil
+		envelope, err := isEnvelope(raw)
+		if err != nil {
+			return err
+		}
+		var t data.Transaction
+		var env *Envelope
+		if envelope {
+			env = &Envelope{}
+			err = json.Unmarshal(raw, env)
+			if err != nil {
+				return fmt.Errorf("failed to decode envelope: %w", err)
+			}
+			t = env.Transaction
+		} else {
+			t, err = unmarshalTransaction(raw)
+			if err != nil {
+				return err
+			}
+		}
+
+		// JSON from other tools may omit the hash.  (An unsigned
+		// transaction's hash remains empty.)
+		if t.GetBase().Hash == emptyHash {
+			err = setHash(t)
+			if err != nil {
+				return err
+			}
 		}
 
-		c <- tx.Transaction // empty metadata discarded here
+		c <- newItem(t, env)
 	}
 
 	return nil
 }
 
-func EncodeOutput(w io.Writer, c chan data.Transaction) error {
-	enc := json.NewEncoder(w)
-	enc.SetIndent("", "\t")
+// unmarshalTransaction decodes a transaction from JSON.  We rely on
+// rubblelabs' ability to decode into TransactionWithMetaData (even
+// though we don't expect metadata to actually be present).  Fields
+// which rubblelabs ignores are decoded here.
+func unmarshalTransaction(b []byte) (data.Transaction, error) {
+	txm := &data.TransactionWithMetaData{}
+	err := json.Unmarshal(b, txm)
+	if err != nil {
+		return nil, err
+	}
+	var extra struct {
+		Signers     []util.Signer
+		Condition   *data.VariableLength
+		Fulfillment *data.VariableLength
+	}
+	err = json.Unmarshal(b, &extra)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s transaction: %w", txm.Transaction.GetType(), err)
+	}
+	t, err := escrow(txm.Transaction, extra.Condition, extra.Fulfillment) // empty metadata discarded here
+	if err != nil {
+		return nil, err
+	}
+	if len(extra.Signers) > 0 {
+		return &util.MultiSigned{Transaction: t, Signers: extra.Signers}, nil
+	}
+	return t, nil
+}
 
-	for tx := range c {
-		err := enc.Encode(tx)
+// escrow converts rubblelabs' escrow transactions to those of package
+// tx, which have Condition and Fulfillment fields.  Other transactions
+// are returned as is.
+func escrow(t data.Transaction, condition, fulfillment *data.VariableLength) (data.Transaction, error) {
+	switch t := t.(type) {
+	case *data.EscrowCreate:
+		if fulfillment != nil {
+			return nil, errors.New("unexpected Fulfillment in EscrowCreate")
+		}
+		return &tx.EscrowCreate{EscrowCreate: *t, Condition: condition}, nil
+	case *data.EscrowFinish:
+		return &tx.EscrowFinish{EscrowFinish: *t, Condition: condition, Fulfillment: fulfillment}, nil
+	}
+	if condition != nil || fulfillment != nil {
+		return nil, fmt.Errorf("unexpected Condition or Fulfillment in %s", t.GetType())
+	}
+	return t, nil
+}
+
+func decodeBlob(c chan Item, r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1024*1024) // larger than any transaction
+	line := 0
+	for scanner.Scan() {
+		line++
+		blob := strings.TrimSpace(scanner.Text())
+		if blob == "" {
+			continue
+		}
+		raw, err := hex.DecodeString(blob)
+		if err != nil {
+			return fmt.Errorf("line %d: expected hex transaction blob: %w", line, err)
+		}
+		tx, err := readTransaction(raw)
+		if err != nil {
+			return fmt.Errorf("line %d: failed to decode transaction blob: %w", line, err)
+		}
+		err = setHash(tx)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", line, err)
+		}
+		c <- newItem(tx, nil)
+	}
+	return scanner.Err()
+}
+
+// setHash computes the hash of a signed transaction.  The hash of an
+// unsigned transaction is left empty, as it will change when signed.
+func setHash(tx data.Transaction) error {
+	base := tx.GetBase()
+	_, multi := tx.(*util.MultiSigned)
+	signed := (base.TxnSignature != nil && len(*base.TxnSignature) > 0) || multi
+	if !signed {
+		return nil
+	}
+	hash, _, err := data.Raw(tx)
+	if err != nil {
+		return fmt.Errorf("failed to hash %s transaction: %w", tx.GetType(), err)
+	}
+	*tx.GetHash() = hash
+	return nil
+}
+
+// DecodeFiles reads transactions from files.  Glob patterns are
+// expanded, for the benefit of inferior operating systems.
+func DecodeFiles(c chan Item, pattern ...string) error {
+	for _, p := range pattern {
+		match, err := filepath.Glob(p)
 		if err != nil {
 			return err
 		}
+		if len(match) == 0 {
+			return fmt.Errorf("expected transaction: file not found (%q)", p)
+		}
+		for _, fname := range match {
+			err = decodeFile(c, fname)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func decodeFile(c chan Item, fname string) error {
+	f, err := os.Open(fname)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	err = DecodeInput(c, f)
+	if err != nil {
+		return fmt.Errorf("failed to decode %q: %w", fname, err)
+	}
+	return nil
+}
+
+// EncodeOutput writes transactions from the channel, in OutputFormat.
+// Commands which compose transactions use this; those which pass
+// transactions along use EncodeItems.
+func EncodeOutput(w io.Writer, c chan data.Transaction) error {
+	items := make(chan Item)
+	go func() {
+		defer close(items)
+		for tx := range c {
+			items <- Item{Transaction: tx}
+		}
+	}()
+	return EncodeItems(w, items)
+}
+
+// EncodeItems writes transactions, with their envelopes, from the
+// channel, in OutputFormat.
+func EncodeItems(w io.Writer, c chan Item) error {
+	return EncodeFormat(w, c, OutputFormat)
+}
+
+// EncodeFormat writes transactions from the channel, in the given
+// format.
+func EncodeFormat(w io.Writer, c chan Item, format string) error {
+	switch format {
+	case FormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "\t")
+
+		for item := range c {
+			err := enc.Encode(item.value())
+			if err != nil {
+				return err
+			}
+		}
+	case FormatBlob:
+		for item := range c {
+			tx := item.Full()
+			_, raw, err := data.Raw(tx)
+			if err != nil {
+				return fmt.Errorf("failed to encode %s transaction: %w", tx.GetType(), err)
+			}
+			_, err = fmt.Fprintf(w, "%X\n", raw)
+			if err != nil {
+				return err
+			}
+		}
+	default:
+		_, err := ParseFormat(format)
+		return err
 	}
 	return nil
 }
@@ -0,0 +1,275 @@
+package pipeline
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/dncohen/rcl/cryptocondition"
+	"github.com/dncohen/rcl/internal/txtest"
+	"github.com/dncohen/rcl/tx"
+	"github.com/dncohen/rcl/util"
+	"github.com/rubblelabs/ripple/data"
+)
+
+func testTx(t *testing.T) data.Transaction {
+	return txtest.AccountSet(t, "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh", 7)
+}
+
+// roundTrip encodes a transaction in format, then decodes it.
+func roundTrip(t *testing.T, format string) (string, data.Transaction) {
+	out := make(chan Item, 1)
+	out <- Item{Transaction: testTx(t)}
+	close(out)
+
+	var buf bytes.Buffer
+	err := EncodeFormat(&buf, out, format)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	in := make(chan Item, 1)
+	err = DecodeInput(in, strings.NewReader("\n"+buf.String()))
+	if err != nil {
+		t.Fatalf("failed to decode %s %q: %s", format, buf.String(), err)
+	}
+	close(in)
+	item := <-in
+	if item.Envelope != nil {
+		t.Errorf("%s: unexpected envelope on bare transaction", format)
+	}
+	return buf.String(), item.Transaction
+}
+
+func TestFormats(t *testing.T) {
+	for _, format := range []string{FormatJSON, FormatBlob} {
+		encoded, tx := roundTrip(t, format)
+		if tx == nil {
+			t.Fatalf("%s: no transaction decoded", format)
+		}
+		if tx.GetType() != "AccountSet" || tx.GetBase().Sequence != 7 {
+			t.Errorf("%s: unexpected transaction %+v", format, tx)
+		}
+		if *tx.GetHash() != emptyHash {
+			t.Errorf("%s: unsigned transaction has hash %s", format, tx.GetHash())
+		}
+		if format == FormatBlob && strings.Count(encoded, "\n") != 1 {
+			t.Errorf("blob: expected one line, got %q", encoded)
+		}
+	}
+}
+
+func TestMultiSigned(t *testing.T) {
+	tx := txtest.Payment(t, "rvYAfWj5gh67oV6fW32ZzP3Aw4Eubs59B", "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh", "100/XRP")
+	var signers []util.Signer
+	for _, keyType := range []data.KeyType{data.ECDSA, data.Ed25519} {
+		pair, err := util.NewKeypair("snoPBrXtMeMyMHUVTgbuqAfg1SUTb", keyType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		signer, err := pair.MultiSign(tx, txtest.Account(t, pair.Address))
+		if err != nil {
+			t.Fatal(err)
+		}
+		signers, err = util.AddSigners(tx, signers, signer)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_, raw, err := data.Raw(&util.MultiSigned{Transaction: tx, Signers: signers})
+	if err != nil {
+		t.Fatal(err)
+	}
+	blob := fmt.Sprintf("%X\n", raw)
+
+	for _, format := range []string{FormatJSON, FormatBlob} {
+		out := make(chan Item, 1)
+		out <- Item{Transaction: tx, Signers: signers}
+		close(out)
+		var buf bytes.Buffer
+		err := EncodeFormat(&buf, out, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		in := make(chan Item, 1)
+		err = DecodeInput(in, &buf)
+		if err != nil {
+			t.Fatalf("%s: %s", format, err)
+		}
+		close(in)
+		item := <-in
+		if !reflect.DeepEqual(item.Signers, signers) {
+			t.Errorf("%s: wanted signers %+v, got %+v", format, signers, item.Signers)
+		}
+		if *item.Transaction.GetHash() != *tx.GetHash() {
+			t.Errorf("%s: wanted hash %s, got %s", format, tx.GetHash(), item.Transaction.GetHash())
+		}
+		err = util.VerifySigners(item.Transaction, item.Signers)
+		if err != nil {
+			t.Errorf("%s: %s", format, err)
+		}
+
+		// decoded blob encodes the same
+		out = make(chan Item, 1)
+		out <- item
+		close(out)
+		buf.Reset()
+		err = EncodeFormat(&buf, out, FormatBlob)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if buf.String() != blob {
+			t.Errorf("%s: wanted blob %s, got %s", format, blob, buf.String())
+		}
+	}
+}
+
+func TestEscrow(t *testing.T) {
+	pair, err := util.NewKeypairFromSecret("snoPBrXtMeMyMHUVTgbuqAfg1SUTb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	preimage, err := cryptocondition.NewPreimage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	finish, err := tx.NewEscrowFinish(
+		tx.SetAddress(pair.Address),
+		tx.SetSequence(3),
+		tx.SetFee(txtest.Fee),
+		tx.SetOwner("rvYAfWj5gh67oV6fW32ZzP3Aw4Eubs59B"),
+		tx.SetOfferSequence(uint32(2)),
+		tx.SetCondition(preimage.Condition()),
+		tx.SetFulfillment(preimage.Fulfillment()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = pair.Sign(finish)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, format := range []string{FormatJSON, FormatBlob} {
+		out := make(chan Item, 1)
+		out <- Item{Transaction: finish}
+		close(out)
+		var buf bytes.Buffer
+		err := EncodeFormat(&buf, out, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		in := make(chan Item, 1)
+		err = DecodeInput(in, &buf)
+		if err != nil {
+			t.Fatalf("%s: %s", format, err)
+		}
+		close(in)
+		decoded, ok := (<-in).Transaction.(*tx.EscrowFinish)
+		if !ok {
+			t.Fatalf("%s: expected EscrowFinish", format)
+		}
+		if decoded.Fulfillment == nil || !bytes.Equal(*decoded.Fulfillment, preimage.Fulfillment()) {
+			t.Errorf("%s: wanted fulfillment %X, got %v", format, preimage.Fulfillment(), decoded.Fulfillment)
+		}
+		if decoded.Condition == nil || !bytes.Equal(*decoded.Condition, preimage.Condition()) {
+			t.Errorf("%s: wanted condition %X, got %v", format, preimage.Condition(), decoded.Condition)
+		}
+		if *decoded.GetHash() != *finish.GetHash() {
+			t.Errorf("%s: wanted hash %s, got %s", format, finish.GetHash(), decoded.GetHash())
+		}
+		// the signature covers the fulfillment
+		err = util.VerifySignature(decoded)
+		if err != nil {
+			t.Errorf("%s: %s", format, err)
+		}
+		*decoded.Fulfillment = append(*decoded.Fulfillment, 0)
+		err = util.VerifySignature(decoded)
+		if err == nil {
+			t.Errorf("%s: altered fulfillment passed verification", format)
+		}
+	}
+}
+
+func TestEnvelope(t *testing.T) {
+	tx := testTx(t)
+	out := make(chan Item, 1)
+	out <- Item{
+		Transaction: tx,
+		Envelope: &Envelope{
+			Composer:    "alice@example",
+			Description: "test",
+			Approvals:   []Approval{{Account: "bob"}},
+		},
+	}
+	close(out)
+	var buf bytes.Buffer
+	err := EncodeFormat(&buf, out, FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"envelope": 1`) {
+		t.Errorf("expected envelope, got %s", buf.String())
+	}
+
+	in := make(chan Item, 1)
+	err = DecodeInput(in, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	close(in)
+	item := <-in
+	decoded, env := item.Transaction, item.Envelope
+	if decoded == tx {
+		t.Fatal("expected a decoded copy")
+	}
+	if env == nil || env.Transaction != decoded || env.Description != "test" || len(env.Approvals) != 1 {
+		t.Errorf("unexpected envelope %+v", env)
+	}
+	if decoded.GetBase().Sequence != 7 {
+		t.Errorf("unexpected transaction %+v", decoded)
+	}
+}
+
+func TestApproval(t *testing.T) {
+	pair, err := util.NewKeypairFromSecret("snoPBrXtMeMyMHUVTgbuqAfg1SUTb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := testTx(t)
+	approval, err := SignApproval(tx, tx.GetBase().Account, pair, "test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// an approval made before signing remains valid after
+	err = pair.Sign(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubkey, err := VerifyApproval(tx, *approval)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *pubkey != pair.PublicKey() {
+		t.Errorf("approval verified with %s, expected %s", pubkey, pair.PublicKey())
+	}
+
+	unsigned := *approval
+	unsigned.Signature = ""
+	_, err = VerifyApproval(tx, unsigned)
+	if err == nil {
+		t.Error("unsigned approval passed verification")
+	}
+
+	tx.GetBase().Sequence++
+	_, err = VerifyApproval(tx, *approval)
+	if err == nil {
+		t.Error("approval of altered transaction passed verification")
+	}
+}
@@ -0,0 +1,329 @@
+// Copyright (C) 2020  David N. Cohen
+// This file is part of github.com/dncohen/rcl
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package policy restricts which transactions rcl-key will sign.
+//
+// A policy file has a section for each signing account, named by
+// nickname or address.  Keys in the unnamed (top) section apply to
+// accounts without a section of their own; when there are none, such
+// accounts may not sign at all.
+//
+//     [treasury]
+//     types = Payment, OfferCreate
+//     destinations = hot, bitstamp-usd
+//     maxamount = 1000/USD, 50000/XRP
+//     dailyamount = 5000/USD, 100000/XRP
+//     maxfee = 1000
+//     memo = required
+//     forbidflags = disablemaster, nofreeze
+//
+// Types lists the transaction types allowed.  Destinations lists the
+// accounts which may receive payments, escrows, checks or payment
+// channels.  Maxamount limits, per currency, what one transaction may
+// spend (the SendMax of a payment, if any, otherwise its Amount; what
+// an offer gives; and so on), and dailyamount what transactions
+// signed in the past 24 hours may spend together.  When either is
+// given, currencies it does not list may not be spent at all, unless
+// it includes "*" (i.e. `maxamount = 1000/USD, *`), which allows
+// other currencies without limit.  Maxfee is in drops.  Memo may be "required".  Forbidflags lists AccountSet flags
+// which may not be set.  Keys omitted impose no restriction.
+//
+// Amounts spent are recorded in a State file, for daily limits.
+package policy
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/dncohen/rcl/tx"
+	"github.com/dncohen/rcl/util"
+	"github.com/go-ini/ini"
+	"github.com/rubblelabs/ripple/data"
+)
+
+// Rule restricts the transactions of one account.  Nil or empty
+// fields impose no restriction.
+type Rule struct {
+	Types        map[string]bool       // lower case
+	Destinations map[data.Account]bool // nil when any destination allowed
+	MaxAmount    map[string]*big.Rat   // currency to limit, per transaction
+	DailyAmount  map[string]*big.Rat   // currency to limit, per rolling 24 hours
+	MaxFee       uint64                // drops
+	RequireMemo  bool
+	ForbidFlags  map[uint32]bool // AccountSet flags
+}
+
+// Policy is the rules of each account, loaded from a file.
+type Policy struct {
+	filename string
+	rules    map[data.Account]*Rule
+	dfault   *Rule // nil when accounts without a rule may not sign
+}
+
+// Resolver converts a nickname or address to an account.
+type Resolver func(string) (data.Account, error)
+
+// Load reads a policy file.  Account names are resolved, i.e. from
+// nicknames in configuration.
+func Load(filename string, resolve Resolver) (*Policy, error) {
+	cfg, err := ini.Load(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load policy: %w", err)
+	}
+
+	p := &Policy{
+		filename: filename,
+		rules:    make(map[data.Account]*Rule),
+	}
+	for _, section := range cfg.Sections() {
+		rule, err := parseRule(section, resolve)
+		if err != nil {
+			return nil, fmt.Errorf("policy %q, section [%s]: %w", filename, section.Name(), err)
+		}
+		if section.Name() == ini.DefaultSection {
+			if len(section.Keys()) > 0 {
+				p.dfault = rule
+			}
+			continue
+		}
+		account, err := resolve(section.Name())
+		if err != nil {
+			return nil, fmt.Errorf("policy %q, section [%s]: %w", filename, section.Name(), err)
+		}
+		p.rules[account] = rule
+	}
+	return p, nil
+}
+
+func (p *Policy) String() string {
+	return p.filename
+}
+
+func parseRule(section *ini.Section, resolve Resolver) (*Rule, error) {
+	rule := &Rule{
+		Types:       make(map[string]bool),
+		ForbidFlags: make(map[uint32]bool),
+	}
+	for _, key := range section.Keys() {
+		var err error
+		switch key.Name() {
+		case "types":
+			for _, typ := range key.Strings(",") {
+				rule.Types[strings.ToLower(typ)] = true
+			}
+		case "destinations":
+			rule.Destinations = make(map[data.Account]bool)
+			for _, name := range key.Strings(",") {
+				account, err := resolve(name)
+				if err != nil {
+					return nil, fmt.Errorf("bad destination %q: %w", name, err)
+				}
+				rule.Destinations[account] = true
+			}
+		case "maxamount":
+			rule.MaxAmount, err = parseLimits(key.Strings(","))
+		case "dailyamount":
+			rule.DailyAmount, err = parseLimits(key.Strings(","))
+		case "maxfee":
+			rule.MaxFee, err = key.Uint64()
+		case "memo":
+			switch key.String() {
+			case "required":
+				rule.RequireMemo = true
+			case "", "optional":
+			default:
+				err = fmt.Errorf("expected memo = required, got %q", key.String())
+			}
+		case "forbidflags":
+			for _, name := range key.Strings(",") {
+				flag, ok := tx.AccountSetFlag[strings.ToLower(name)]
+				if !ok {
+					return nil, fmt.Errorf("unknown AccountSet flag %q", name)
+				}
+				rule.ForbidFlags[flag] = true
+			}
+		default:
+			err = fmt.Errorf("unknown key %q", key.Name())
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", key.Name(), err)
+		}
+	}
+	return rule, nil
+}
+
+// parseLimits parses amounts like "1000/USD", which limit all issuers
+// of a currency together.  "*" allows currencies not listed, and is
+// recorded with a nil limit.
+func parseLimits(arg []string) (map[string]*big.Rat, error) {
+	limit := make(map[string]*big.Rat)
+	for _, a := range arg {
+		if a == anyCurrency {
+			limit[anyCurrency] = nil
+			continue
+		}
+		part := strings.Split(a, "/")
+		if len(part) != 2 {
+			return nil, fmt.Errorf("expected <value>/<currency>, got %q", a)
+		}
+		value, ok := new(big.Rat).SetString(part[0])
+		if !ok || value.Sign() < 0 {
+			return nil, fmt.Errorf("bad value in %q", a)
+		}
+		limit[strings.ToUpper(part[1])] = value
+	}
+	return limit, nil
+}
+
+// in limits, allows currencies not listed
+const anyCurrency = "*"
+
+// limitOf returns the limit of a currency, nil when unlimited, and
+// whether the currency may be spent at all.
+func limitOf(limits map[string]*big.Rat, currency string) (*big.Rat, bool) {
+	if limits == nil {
+		return nil, true
+	}
+	if limit, ok := limits[currency]; ok {
+		return limit, true
+	}
+	_, ok := limits[anyCurrency]
+	return nil, ok
+}
+
+// Check returns nil when the policy permits an account to sign a
+// transaction.  Otherwise, the error explains which rule forbids it.
+// Daily limits consider what state records as spent.
+func (p *Policy) Check(t data.Transaction, state *State) error {
+	base := t.GetBase()
+	rule, ok := p.rules[base.Account]
+	if !ok {
+		rule = p.dfault
+	}
+	if rule == nil {
+		return fmt.Errorf("policy has no rule for account %s", base.Account)
+	}
+
+	if len(rule.Types) > 0 && !rule.Types[strings.ToLower(t.GetType())] {
+		return fmt.Errorf("%s transactions are not allowed", t.GetType())
+	}
+
+	if rule.MaxFee > 0 {
+		drops, err := util.Drops(data.Amount{Value: &base.Fee})
+		if err != nil {
+			return err
+		}
+		if drops > rule.MaxFee {
+			return fmt.Errorf("fee %d drops exceeds maxfee %d", drops, rule.MaxFee)
+		}
+	}
+
+	if rule.RequireMemo && len(base.Memos) == 0 {
+		return fmt.Errorf("memo required")
+	}
+
+	if as, ok := t.(*data.AccountSet); ok && as.SetFlag != nil && rule.ForbidFlags[*as.SetFlag] {
+		return fmt.Errorf("AccountSet flag %d is forbidden", *as.SetFlag)
+	}
+
+	if destination := Destination(t); destination != nil && rule.Destinations != nil && !rule.Destinations[*destination] {
+		return fmt.Errorf("destination %s is not allowed", destination)
+	}
+
+	if spend := Spend(t); spend != nil {
+		currency := Currency(*spend)
+		value := value(*spend)
+		limit, ok := limitOf(rule.MaxAmount, currency)
+		if !ok {
+			return fmt.Errorf("%s is not listed in maxamount", currency)
+		}
+		if limit != nil && value.Cmp(limit) > 0 {
+			return fmt.Errorf("amount %s %s exceeds maxamount %s", value.FloatString(6), currency, limit.FloatString(6))
+		}
+		limit, ok = limitOf(rule.DailyAmount, currency)
+		if !ok {
+			return fmt.Errorf("%s is not listed in dailyamount", currency)
+		}
+		if limit != nil {
+			total := new(big.Rat).Add(state.Total(base.Account, currency), value)
+			if total.Cmp(limit) > 0 {
+				return fmt.Errorf("amount %s %s would bring the past 24 hours' total to %s, exceeding dailyamount %s", value.FloatString(6), currency, total.FloatString(6), limit.FloatString(6))
+			}
+		}
+	}
+
+	return nil
+}
+
+// value returns an amount in units of its currency, as limits are.
+// That is, native amounts in XRP rather than drops.
+func value(amount data.Amount) *big.Rat {
+	v := amount.Value.Rat()
+	if amount.IsNative() {
+		v.Quo(v, big.NewRat(dropsPerXRP, 1))
+	}
+	return v
+}
+
+const dropsPerXRP = 1000000
+
+// Destination returns the account which receives what a transaction
+// sends, if any.
+func Destination(t data.Transaction) *data.Account {
+	switch t := t.(type) {
+	case *data.Payment:
+		return &t.Destination
+	case *tx.EscrowCreate:
+		return &t.Destination
+	case *data.CheckCreate:
+		return &t.Destination
+	case *data.PaymentChannelCreate:
+		return &t.Destination
+	}
+	return nil
+}
+
+// Spend returns the most a transaction may spend, if anything (apart
+// from its fee).
+func Spend(t data.Transaction) *data.Amount {
+	switch t := t.(type) {
+	case *data.Payment:
+		if t.SendMax != nil {
+			return t.SendMax
+		}
+		return &t.Amount
+	case *data.OfferCreate:
+		return &t.TakerGets
+	case *data.CheckCreate:
+		return &t.SendMax
+	case *tx.EscrowCreate:
+		return &t.Amount
+	case *data.PaymentChannelCreate:
+		return &t.Amount
+	case *data.PaymentChannelFund:
+		return &t.Amount
+	}
+	return nil
+}
+
+// Currency of an amount, all issuers alike.
+func Currency(amount data.Amount) string {
+	if amount.IsNative() {
+		return "XRP"
+	}
+	return amount.Currency.String()
+}
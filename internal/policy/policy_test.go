@@ -0,0 +1,120 @@
+package policy
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/dncohen/rcl/internal/txtest"
+	"github.com/rubblelabs/ripple/data"
+)
+
+const (
+	testAccount     = "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh"
+	testDestination = "rvYAfWj5gh67oV6fW32ZzP3Aw4Eubs59B"
+)
+
+const testPolicy = `
+[treasury]
+types = Payment
+destinations = bitstamp
+maxamount = 100/XRP
+dailyamount = 150/XRP
+maxfee = 100
+`
+
+func resolve(name string) (data.Account, error) {
+	switch name {
+	case "treasury":
+		name = testAccount
+	case "bitstamp":
+		name = testDestination
+	}
+	account, err := data.NewAccountFromAddress(name)
+	if err != nil {
+		return data.Account{}, err
+	}
+	return *account, nil
+}
+
+func TestCheck(t *testing.T) {
+	dir, err := ioutil.TempDir("", "policy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	filename := filepath.Join(dir, "rcl-key.policy")
+	err = ioutil.WriteFile(filename, []byte(testPolicy), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := Load(filename, resolve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err := OpenState(filepath.Join(dir, "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ok := txtest.Payment(t, testAccount, testDestination, "80/XRP")
+	if err := p.Check(ok, state); err != nil {
+		t.Errorf("expected payment allowed, got %s", err)
+	}
+	if err := state.Record(ok); err != nil {
+		t.Fatal(err)
+	}
+	if total := state.Total(txtest.Account(t, testAccount), "XRP"); total.Cmp(big.NewRat(80, 1)) != 0 {
+		t.Errorf("expected 80 XRP spent, got %s", total.FloatString(6))
+	}
+
+	if err := p.Check(txtest.Payment(t, testAccount, testDestination, "101/XRP"), state); err == nil {
+		t.Error("expected payment over maxamount rejected")
+	}
+	if err := p.Check(txtest.Payment(t, testAccount, testDestination, "80/XRP"), state); err == nil {
+		t.Error("expected payment over dailyamount rejected")
+	}
+
+	// state is saved
+	state, err = OpenState(filepath.Join(dir, "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Check(txtest.Payment(t, testAccount, testDestination, "80/XRP"), state); err == nil {
+		t.Error("expected payment over dailyamount rejected, after reopening state")
+	}
+
+	elsewhere := txtest.Payment(t, testAccount, testDestination, "1/XRP")
+	elsewhere.Destination = elsewhere.Account
+	if err := p.Check(elsewhere, state); err == nil {
+		t.Error("expected destination not allowed")
+	}
+
+	other := txtest.Payment(t, testAccount, testDestination, "1/XRP")
+	other.Account = other.Destination
+	if err := p.Check(other, state); err == nil {
+		t.Error("expected account without rule rejected")
+	}
+
+	// currencies not listed in limits are forbidden, unless "*" is
+	eur := txtest.Payment(t, testAccount, testDestination, "1/EUR/"+testDestination)
+	if err := p.Check(eur, state); err == nil {
+		t.Error("expected currency not in maxamount rejected")
+	}
+	anyPolicy := strings.NewReplacer("100/XRP", "100/XRP, *", "150/XRP", "150/XRP, *").Replace(testPolicy)
+	err = ioutil.WriteFile(filename, []byte(anyPolicy), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err = Load(filename, resolve)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Check(eur, state); err != nil {
+		t.Errorf("expected currency allowed by \"*\", got %s", err)
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright (C) 2020  David N. Cohen
+// This file is part of github.com/dncohen/rcl
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package policy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"time"
+
+	"github.com/dncohen/rcl/internal/atomicfile"
+	"github.com/rubblelabs/ripple/data"
+)
+
+// Day is the period of daily limits.
+const Day = 24 * time.Hour
+
+// Spent is what one signed transaction may spend.
+type Spent struct {
+	Time     time.Time    `json:"time"`
+	Account  data.Account `json:"account"`
+	Hash     string       `json:"hash"`
+	Currency string       `json:"currency"`
+	Value    string       `json:"value"` // exact, as big.Rat (XRP, not drops)
+}
+
+// State records amounts spent by signed transactions, for daily
+// limits.  Records older than a Day are discarded.
+type State struct {
+	filename string
+	Spent    []Spent `json:"spent"`
+}
+
+// OpenState reads a state file, or begins a new one if the file does
+// not exist.
+func OpenState(filename string) (*State, error) {
+	state := &State{filename: filename}
+	b, err := ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(b, state)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode policy state %q: %w", filename, err)
+	}
+	return state, nil
+}
+
+func (state *State) String() string {
+	return state.filename
+}
+
+// Total returns what an account has spent, of a currency, in the
+// past Day.
+func (state *State) Total(account data.Account, currency string) *big.Rat {
+	since := time.Now().Add(-Day)
+	total := new(big.Rat)
+	for _, spent := range state.Spent {
+		if spent.Account != account || spent.Currency != currency || spent.Time.Before(since) {
+			continue
+		}
+		value, ok := new(big.Rat).SetString(spent.Value)
+		if ok {
+			total.Add(total, value)
+		}
+	}
+	return total
+}
+
+// Record notes what a signed transaction may spend, and saves the
+// state file.
+func (state *State) Record(t data.Transaction) error {
+	spend := Spend(t)
+	if spend == nil {
+		return nil
+	}
+
+	now := time.Now()
+	since := now.Add(-Day)
+	var keep []Spent
+	for _, spent := range state.Spent {
+		if !spent.Time.Before(since) {
+			keep = append(keep, spent)
+		}
+	}
+	state.Spent = append(keep, Spent{
+		Time:     now.UTC(),
+		Account:  t.GetBase().Account,
+		Hash:     t.GetHash().String(),
+		Currency: Currency(*spend),
+		Value:    value(*spend).RatString(),
+	})
+	return state.write()
+}
+
+// write replaces the state file.
+func (state *State) write() error {
+	b, err := json.MarshalIndent(state, "", "\t")
+	if err != nil {
+		return err
+	}
+	return atomicfile.Write(state.filename, b, 0600)
+}
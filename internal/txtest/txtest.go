@@ -0,0 +1,74 @@
+// Copyright (C) 2020  David N. Cohen
+// This file is part of github.com/dncohen/rcl
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Package txtest builds transactions for tests.
+package txtest
+
+import (
+	"testing"
+
+	"github.com/rubblelabs/ripple/data"
+)
+
+// Fee, in drops, of transactions built here.
+const Fee = 12
+
+// Account parses an address.
+func Account(t testing.TB, address string) data.Account {
+	t.Helper()
+	account, err := data.NewAccountFromAddress(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return *account
+}
+
+func base(t testing.TB, typ data.TransactionType, account string, sequence uint32) data.TxBase {
+	t.Helper()
+	fee, err := data.NewNativeValue(Fee)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data.TxBase{
+		TransactionType: typ,
+		Account:         Account(t, account),
+		Sequence:        sequence,
+		Fee:             *fee,
+	}
+}
+
+// AccountSet returns an unsigned AccountSet, changing nothing.
+func AccountSet(t testing.TB, account string, sequence uint32) *data.AccountSet {
+	t.Helper()
+	return &data.AccountSet{
+		TxBase: base(t, data.ACCOUNT_SET, account, sequence),
+	}
+}
+
+// Payment returns an unsigned payment of amount, i.e. "100/XRP" or
+// "1/USD/<issuer>".
+func Payment(t testing.TB, from, to, amount string) *data.Payment {
+	t.Helper()
+	a, err := data.NewAmount(amount)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &data.Payment{
+		TxBase:      base(t, data.PAYMENT, from, 0),
+		Destination: Account(t, to),
+		Amount:      *a,
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright (C) 2020  David N. Cohen
+// This file is part of github.com/dncohen/rcl
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package paychan
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/dncohen/rcl/util"
+	"github.com/rubblelabs/ripple/data"
+)
+
+// PayRequest asks a payer to authorize a cumulative amount.
+type PayRequest struct {
+	Channel data.Hash256 `json:"channel"`
+	Amount  data.Amount  `json:"amount"`
+}
+
+// Handler serves a payer, a payee, or both (either may be nil).
+//
+//   POST /pay                 PayRequest, responds with signed claim
+//   GET  /pay?channel=<id>    latest claim signed
+//   POST /receive             claim, responds with best claim received
+//   GET  /receive?channel=<id> best claim received
+//
+// Claims are JSON encoded, as util.Claim.  The handler does no
+// authentication, so should listen only on a local or otherwise
+// protected address.
+func Handler(payer *Payer, payee *Payee) http.Handler {
+	mux := http.NewServeMux()
+	if payer != nil {
+		mux.HandleFunc("/pay", func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet:
+				channel, ok := channelParam(w, r)
+				if ok {
+					claim, err := payer.Last(channel)
+					respond(w, claim, err)
+				}
+			case http.MethodPost:
+				var req PayRequest
+				err := json.NewDecoder(r.Body).Decode(&req)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				claim, err := payer.Authorize(req.Channel, req.Amount)
+				respond(w, claim, err)
+			default:
+				http.Error(w, "expected GET or POST", http.StatusMethodNotAllowed)
+			}
+		})
+	}
+	if payee != nil {
+		mux.HandleFunc("/receive", func(w http.ResponseWriter, r *http.Request) {
+			switch r.Method {
+			case http.MethodGet:
+				channel, ok := channelParam(w, r)
+				if ok {
+					claim, err := payee.Best(channel)
+					respond(w, claim, err)
+				}
+			case http.MethodPost:
+				claim := &util.Claim{}
+				err := json.NewDecoder(r.Body).Decode(claim)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				best, err := payee.Receive(claim)
+				respond(w, best, err)
+			default:
+				http.Error(w, "expected GET or POST", http.StatusMethodNotAllowed)
+			}
+		})
+	}
+	return mux
+}
+
+func channelParam(w http.ResponseWriter, r *http.Request) (data.Hash256, bool) {
+	id, err := data.NewHash256(r.URL.Query().Get("channel"))
+	if err != nil {
+		http.Error(w, "expected channel=<id>", http.StatusBadRequest)
+		return data.Hash256{}, false
+	}
+	return *id, true
+}
+
+func respond(w http.ResponseWriter, claim *util.Claim, err error) {
+	switch {
+	case errors.Is(err, ErrBadClaim):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, ErrNotIncreasing), errors.Is(err, ErrExceedsChannel), errors.Is(err, ErrWrongKey):
+		http.Error(w, err.Error(), http.StatusConflict)
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	case claim == nil:
+		http.Error(w, "no claim", http.StatusNotFound)
+	default:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(claim)
+	}
+}
@@ -0,0 +1,217 @@
+// Copyright (C) 2020  David N. Cohen
+// This file is part of github.com/dncohen/rcl
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+// Paychan package
+//
+// Off-ledger payments over payment channels.  A Payer signs claims,
+// each authorizing a greater cumulative amount than the last, and
+// never more than the channel holds on-ledger.  A Payee verifies the
+// claims it receives, and keeps the best one, to redeem on-ledger
+// later.  Both persist the latest claim of each channel in a Store,
+// so that a restart does not lose (or repeat) authorizations.
+//
+// Handler serves both sides over HTTP, see `rcl-account
+// channel-serve`.
+//
+package paychan
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/dncohen/rcl/rpc"
+	"github.com/dncohen/rcl/tx"
+	"github.com/dncohen/rcl/util"
+	"github.com/rubblelabs/ripple/data"
+)
+
+var (
+	ErrNotIncreasing  = errors.New("claim does not exceed previous claim")
+	ErrExceedsChannel = errors.New("claim exceeds channel amount")
+	ErrWrongKey       = errors.New("channel public key does not match")
+	ErrBadClaim       = errors.New("bad claim")
+)
+
+// Ledger provides the on-ledger state of payment channels.
+// *rpc.Websocket satisfies this interface.
+type Ledger interface {
+	PaymentChannel(id data.Hash256) (*rpc.PaymentChannel, error)
+}
+
+// Payer signs claims on channels whose public key is its keypair's.
+type Payer struct {
+	keypair util.Keypair
+	ledger  Ledger
+	store   *Store
+	mu      sync.Mutex // one authorization at a time
+}
+
+func NewPayer(keypair util.Keypair, ledger Ledger, store *Store) *Payer {
+	return &Payer{
+		keypair: keypair,
+		ledger:  ledger,
+		store:   store,
+	}
+}
+
+// Authorize signs a claim for amount, the cumulative total paid over
+// the channel.  Amount must exceed the previous claim, and not exceed
+// the channel's amount on-ledger.  The claim is stored before it is
+// returned.
+func (p *Payer) Authorize(channel data.Hash256, amount data.Amount) (*util.Claim, error) {
+	drops, err := util.Drops(amount)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	prev, err := p.store.Get(channel)
+	if err != nil {
+		return nil, err
+	}
+	if prev != nil {
+		prevDrops, err := util.Drops(prev.Amount)
+		if err != nil {
+			return nil, err
+		}
+		if drops <= prevDrops {
+			return nil, fmt.Errorf("%w (%s, previous %s)", ErrNotIncreasing, amount, prev.Amount)
+		}
+	}
+
+	ch, err := p.ledger.PaymentChannel(channel)
+	if err != nil {
+		return nil, err
+	}
+	if ch.PublicKey != p.keypair.PublicKey() {
+		return nil, fmt.Errorf("%w (channel %s)", ErrWrongKey, channel)
+	}
+	err = checkChannelAmount(ch, drops)
+	if err != nil {
+		return nil, err
+	}
+
+	claim, err := p.keypair.SignClaim(channel, amount)
+	if err != nil {
+		return nil, err
+	}
+	err = p.store.Put(claim)
+	if err != nil {
+		return nil, err
+	}
+	return claim, nil
+}
+
+// Last returns the latest claim signed for a channel, or nil.
+func (p *Payer) Last(channel data.Hash256) (*util.Claim, error) {
+	return p.store.Get(channel)
+}
+
+// Payee verifies and keeps claims received.
+type Payee struct {
+	ledger Ledger
+	store  *Store
+	mu     sync.Mutex
+}
+
+func NewPayee(ledger Ledger, store *Store) *Payee {
+	return &Payee{
+		ledger: ledger,
+		store:  store,
+	}
+}
+
+// Receive verifies a claim, against the channel's public key and
+// amount on-ledger.  A valid claim is stored if it exceeds the best
+// claim already received.  The best claim is returned.
+func (r *Payee) Receive(claim *util.Claim) (*util.Claim, error) {
+	drops, err := util.Drops(claim.Amount)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBadClaim, err)
+	}
+
+	ch, err := r.ledger.PaymentChannel(claim.Channel)
+	if err != nil {
+		return nil, err
+	}
+	err = util.VerifyClaim(claim, ch.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBadClaim, err)
+	}
+	err = checkChannelAmount(ch, drops)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	best, err := r.store.Get(claim.Channel)
+	if err != nil {
+		return nil, err
+	}
+	if best != nil {
+		bestDrops, err := util.Drops(best.Amount)
+		if err != nil {
+			return nil, err
+		}
+		if drops <= bestDrops {
+			return best, nil // keep the better claim
+		}
+	}
+	err = r.store.Put(claim)
+	if err != nil {
+		return nil, err
+	}
+	return claim, nil
+}
+
+// Best returns the greatest claim received on a channel, or nil.
+func (r *Payee) Best(channel data.Hash256) (*util.Claim, error) {
+	return r.store.Get(channel)
+}
+
+// Redeem composes a transaction claiming the best claim received.
+// Options typically include account, sequence and fee.
+func (r *Payee) Redeem(channel data.Hash256, options ...func(data.Transaction) error) (*data.PaymentChannelClaim, error) {
+	best, err := r.Best(channel)
+	if err != nil {
+		return nil, err
+	}
+	if best == nil {
+		return nil, fmt.Errorf("no claim received on channel %s", channel)
+	}
+	return tx.NewPaymentChannelClaim(append(options,
+		tx.SetChannel(channel),
+		tx.SetClaimBalance(best.Amount),
+		tx.SetClaimAmount(best.Amount),
+		tx.SetClaimSignature(best.Signature, best.PublicKey),
+	)...)
+}
+
+func checkChannelAmount(ch *rpc.PaymentChannel, drops uint64) error {
+	available, err := util.Drops(ch.Amount)
+	if err != nil {
+		return err
+	}
+	if drops > available {
+		return fmt.Errorf("%w (%d drops, channel %s holds %s)", ErrExceedsChannel, drops, ch.Index, ch.Amount)
+	}
+	return nil
+}
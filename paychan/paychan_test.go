@@ -0,0 +1,241 @@
+package paychan
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/dncohen/rcl/rpc"
+	"github.com/dncohen/rcl/tx"
+	"github.com/dncohen/rcl/util"
+	"github.com/gorilla/websocket"
+	"github.com/rubblelabs/ripple/data"
+)
+
+// well known test secret, not for use with real funds
+const testSecret = "snoPBrXtMeMyMHUVTgbuqAfg1SUTb"
+
+const testChannel = "C7F634794B79DB40E87179A9D1BF05D05797AE7E92DF8E93FD6656E8C4BE3AE7"
+
+// fakeRippled answers ledger_entry requests for the given channels,
+// with any other request an error.
+func fakeRippled(t *testing.T, channels map[string]interface{}) *httptest.Server {
+	upgrader := websocket.Upgrader{}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer conn.Close()
+		for {
+			var req map[string]interface{}
+			if err := conn.ReadJSON(&req); err != nil {
+				return
+			}
+			resp := map[string]interface{}{
+				"id":     req["id"],
+				"type":   "response",
+				"status": "error",
+				"error":  "entryNotFound",
+			}
+			id, _ := req["payment_channel"].(string)
+			if node, ok := channels[id]; ok && req["command"] == "ledger_entry" {
+				resp["status"] = "success"
+				resp["result"] = map[string]interface{}{
+					"index":        id,
+					"ledger_index": 1000,
+					"node":         node,
+					"validated":    true,
+				}
+			}
+			if err := conn.WriteJSON(resp); err != nil {
+				return
+			}
+		}
+	}))
+}
+
+func drops(t *testing.T, n int64) data.Amount {
+	v, err := data.NewNativeValue(n)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data.Amount{Value: v}
+}
+
+// setup returns a keypair, a connection to fake rippled with a channel
+// of 1 XRP, the channel ID, a temporary directory, and cleanup
+// function.
+func setup(t *testing.T) (util.Keypair, *rpc.Websocket, data.Hash256, string, func()) {
+	kp, err := util.NewKeypairFromSecret(testSecret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pubkey := kp.PublicKey()
+
+	srv := fakeRippled(t, map[string]interface{}{
+		testChannel: map[string]interface{}{
+			"LedgerEntryType": "PayChannel",
+			"Account":         kp.Address,
+			"Destination":     "rDsbeomae4FXwgQTJp9Rs64Qg9vDiTCdBv",
+			"Amount":          "1000000",
+			"Balance":         "0",
+			"PublicKey":       strings.ToUpper(hex.EncodeToString(pubkey[:])),
+			"SettleDelay":     86400,
+		},
+	})
+
+	ws, err := rpc.NewWebsocket("ws" + strings.TrimPrefix(srv.URL, "http"))
+	if err != nil {
+		srv.Close()
+		t.Fatal(err)
+	}
+
+	channel, err := data.NewHash256(testChannel)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "paychan")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cleanup := func() {
+		ws.Close()
+		srv.Close()
+		os.RemoveAll(dir)
+	}
+	return kp, ws, *channel, dir, cleanup
+}
+
+func TestPayerPayee(t *testing.T) {
+	kp, ws, channel, dir, cleanup := setup(t)
+	defer cleanup()
+
+	payerStore, err := NewStore(dir + "/signed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	payer := NewPayer(kp, ws, payerStore)
+
+	claim, err := payer.Authorize(channel, drops(t, 100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := payer.Authorize(channel, drops(t, 100)); !errors.Is(err, ErrNotIncreasing) {
+		t.Errorf("expected %v, got %v", ErrNotIncreasing, err)
+	}
+	if _, err := payer.Authorize(channel, drops(t, 1000001)); !errors.Is(err, ErrExceedsChannel) {
+		t.Errorf("expected %v, got %v", ErrExceedsChannel, err)
+	}
+
+	// a restarted payer remembers the last claim
+	restarted := NewPayer(kp, ws, payerStore)
+	if _, err := restarted.Authorize(channel, drops(t, 50)); !errors.Is(err, ErrNotIncreasing) {
+		t.Errorf("expected %v after restart, got %v", ErrNotIncreasing, err)
+	}
+	better, err := restarted.Authorize(channel, drops(t, 200))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payeeStore, err := NewStore(dir + "/received")
+	if err != nil {
+		t.Fatal(err)
+	}
+	payee := NewPayee(ws, payeeStore)
+
+	best, err := payee.Receive(better)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// an earlier, lesser claim does not replace the best
+	best, err = payee.Receive(claim)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if best.Amount.String() != better.Amount.String() {
+		t.Errorf("best claim %s, expected %s", best.Amount, better.Amount)
+	}
+
+	forged := *claim
+	forged.Amount = drops(t, 500)
+	if _, err := payee.Receive(&forged); !errors.Is(err, ErrBadClaim) {
+		t.Errorf("expected %v, got %v", ErrBadClaim, err)
+	}
+
+	redeem, err := payee.Redeem(channel,
+		tx.SetAddress("rDsbeomae4FXwgQTJp9Rs64Qg9vDiTCdBv"),
+		tx.SetSequence(1),
+		tx.SetFee(12),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if redeem.Channel != channel || redeem.Signature == nil || redeem.Sequence != 1 {
+		t.Errorf("unexpected redeem transaction %+v", redeem)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	kp, ws, channel, dir, cleanup := setup(t)
+	defer cleanup()
+
+	store, err := NewStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(Handler(NewPayer(kp, ws, store), nil))
+	defer srv.Close()
+
+	pay := func(n int64) int {
+		b, err := json.Marshal(PayRequest{Channel: channel, Amount: drops(t, n)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.Post(srv.URL+"/pay", "application/json", bytes.NewReader(b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := pay(100); code != http.StatusOK {
+		t.Errorf("pay 100: status %d", code)
+	}
+	if code := pay(100); code != http.StatusConflict {
+		t.Errorf("pay 100 again: status %d", code)
+	}
+
+	resp, err := http.Get(srv.URL + "/pay?channel=" + testChannel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	claim := &util.Claim{}
+	if err := json.NewDecoder(resp.Body).Decode(claim); err != nil {
+		t.Fatal(err)
+	}
+	if err := util.VerifyClaim(claim, kp.PublicKey()); err != nil {
+		t.Error(err)
+	}
+
+	resp, err = http.Get(srv.URL + "/receive?channel=" + testChannel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("receive without payee: status %d", resp.StatusCode)
+	}
+}
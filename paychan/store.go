@@ -0,0 +1,74 @@
+// Copyright (C) 2020  David N. Cohen
+// This file is part of github.com/dncohen/rcl
+//
+// This program is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Affero General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// This program is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Affero General Public License for more details.
+//
+// You should have received a copy of the GNU Affero General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package paychan
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/dncohen/rcl/internal/atomicfile"
+	"github.com/dncohen/rcl/util"
+	"github.com/rubblelabs/ripple/data"
+)
+
+// Store keeps one claim per channel, as a JSON file named for the
+// channel ID.
+type Store struct {
+	dir string
+}
+
+// NewStore uses (and if needed, creates) a directory.
+func NewStore(dir string) (*Store, error) {
+	err := os.MkdirAll(dir, 0700)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{dir: dir}, nil
+}
+
+func (s *Store) filename(channel data.Hash256) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%s.json", channel))
+}
+
+// Get returns the stored claim of a channel, or nil if none.
+func (s *Store) Get(channel data.Hash256) (*util.Claim, error) {
+	b, err := ioutil.ReadFile(s.filename(channel))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	claim := &util.Claim{}
+	err = json.Unmarshal(b, claim)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse claim %q: %w", s.filename(channel), err)
+	}
+	return claim, nil
+}
+
+// Put replaces the stored claim of a channel.
+func (s *Store) Put(claim *util.Claim) error {
+	b, err := json.MarshalIndent(claim, "", "\t")
+	if err != nil {
+		return err
+	}
+	return atomicfile.Write(s.filename(claim.Channel), b, 0600)
+}
@@ -0,0 +1,123 @@
+package rpc
+
+import (
+	"encoding/json"
+
+	"github.com/rubblelabs/ripple/data"
+)
+
+// {
+//   "command": "account_objects",
+//   "account": "r9cZA1mLK5R5Am25ArfXFmqgNwjZgnfk59",
+//   "type": "signer_list",
+//   "ledger_index": "validated"
+// }
+type AccountObjectsParams struct {
+	Account     string          `json:"account"`
+	Type        string          `json:"type,omitempty"`
+	LedgerIndex interface{}     `json:"ledger_index,omitempty"`
+	Limit       int             `json:"limit,omitempty"`
+	Marker      json.RawMessage `json:"marker,omitempty"`
+}
+
+type AccountObjectsResult struct {
+	Account        string            `json:"account"`
+	AccountObjects []json.RawMessage `json:"account_objects"`
+	LedgerIndex    uint32            `json:"ledger_index"`
+	Validated      bool              `json:"validated"`
+	Marker         json.RawMessage   `json:"marker,omitempty"`
+}
+
+// AccountObjects returns all objects of a type (i.e. "signer_list",
+// "check", "escrow", "payment_channel") owned by an account, in the
+// most recent validated ledger.  Objects are left encoded, for the
+// caller to decode into the appropriate type.
+func (ws *Websocket) AccountObjects(account data.Account, typ string) ([]json.RawMessage, error) {
+	params := AccountObjectsParams{
+		Account:     account.String(),
+		Type:        typ,
+		LedgerIndex: "validated",
+	}
+
+	var objects []json.RawMessage
+	for {
+		var result AccountObjectsResult
+		err := ws.Request("account_objects", params, &result)
+		if err != nil {
+			return objects, err
+		}
+		objects = append(objects, result.AccountObjects...)
+		if len(result.Marker) == 0 {
+			break
+		}
+		params.Marker = result.Marker
+		params.LedgerIndex = result.LedgerIndex // page through same ledger
+	}
+	return objects, nil
+}
+
+//   {
+//     "LedgerEntryType": "SignerList",
+//     "SignerQuorum": 3,
+//     "SignerEntries": [
+//       {"SignerEntry": {"Account": "rsA2LpzuawewSBQXkiju3YQTMzW13pAAdW", "SignerWeight": 2}},
+//       ...
+//     ],
+//     ...
+//   }
+type SignerList struct {
+	LedgerEntryType string
+	SignerQuorum    uint32
+	SignerEntries   []struct {
+		SignerEntry SignerEntry
+	}
+}
+
+type SignerEntry struct {
+	Account      data.Account
+	SignerWeight uint16
+}
+
+// SignerList returns an account's signer list, or nil if the account
+// has none.
+func (ws *Websocket) SignerList(account data.Account) (*SignerList, error) {
+	objects, err := ws.AccountObjects(account, "signer_list")
+	if err != nil || len(objects) == 0 {
+		return nil, err
+	}
+	list := &SignerList{}
+	err = json.Unmarshal(objects[0], list)
+	return list, err
+}
+
+// Check is an outstanding check.  Index is the check's ID, used to
+// cash or cancel it.
+type Check struct {
+	LedgerEntryType string
+	Index           data.Hash256 `json:"index"`
+	Account         data.Account
+	Destination     data.Account
+	SendMax         data.Amount
+	Sequence        uint32
+	Expiration      *uint32       `json:",omitempty"`
+	InvoiceID       *data.Hash256 `json:",omitempty"`
+	DestinationTag  *uint32       `json:",omitempty"`
+	SourceTag       *uint32       `json:",omitempty"`
+}
+
+// Checks returns the outstanding checks of an account, both those it
+// has sent and those it may cash.
+func (ws *Websocket) Checks(account data.Account) ([]Check, error) {
+	objects, err := ws.AccountObjects(account, "check")
+	if err != nil {
+		return nil, err
+	}
+	checks := make([]Check, len(objects))
+	for i, obj := range objects {
+		err = json.Unmarshal(obj, &checks[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return checks, nil
+}
@@ -0,0 +1,37 @@
+package rpc
+
+import "github.com/rubblelabs/ripple/data"
+
+// {
+//   "command": "deposit_authorized",
+//   "source_account": "rEhxGqkqPPSxQ3P25J66ft5TwpzV14k2de",
+//   "destination_account": "rsUiUMpnrgxQp24dJYZDhmV4bE3aBtQyt8",
+//   "ledger_index": "validated"
+// }
+type DepositAuthorizedParams struct {
+	SourceAccount      string      `json:"source_account"`
+	DestinationAccount string      `json:"destination_account"`
+	LedgerIndex        interface{} `json:"ledger_index,omitempty"`
+}
+
+type DepositAuthorizedResult struct {
+	DepositAuthorized  bool   `json:"deposit_authorized"`
+	SourceAccount      string `json:"source_account"`
+	DestinationAccount string `json:"destination_account"`
+	LedgerIndex        uint32 `json:"ledger_index"`
+	Validated          bool   `json:"validated"`
+}
+
+// DepositAuthorized returns true when source may send payments to
+// destination.  Destinations with DepositAuth enabled accept payments
+// only from preauthorized accounts.
+func (ws *Websocket) DepositAuthorized(source, destination data.Account) (bool, error) {
+	params := DepositAuthorizedParams{
+		SourceAccount:      source.String(),
+		DestinationAccount: destination.String(),
+		LedgerIndex:        "validated",
+	}
+	var result DepositAuthorizedResult
+	err := ws.Request("deposit_authorized", params, &result)
+	return result.DepositAuthorized, err
+}
@@ -0,0 +1,57 @@
+package rpc
+
+import (
+	"encoding/json"
+	"strconv"
+)
+
+// {"command": "fee"}
+//
+// Fee levels are in drops, for a reference transaction (costing one
+// fee unit).
+type FeeResult struct {
+	CurrentLedgerSize  string `json:"current_ledger_size"`
+	CurrentQueueSize   string `json:"current_queue_size"`
+	ExpectedLedgerSize string `json:"expected_ledger_size"`
+	LedgerCurrentIndex uint32 `json:"ledger_current_index"`
+	MaxQueueSize       string `json:"max_queue_size"`
+	Drops              struct {
+		BaseFee       json.Number `json:"base_fee"`
+		MedianFee     json.Number `json:"median_fee"`
+		MinimumFee    json.Number `json:"minimum_fee"`
+		OpenLedgerFee json.Number `json:"open_ledger_fee"`
+	} `json:"drops"`
+}
+
+// Fee returns current transaction cost, and load on the network.
+func (ws *Websocket) Fee() (*FeeResult, error) {
+	result := &FeeResult{}
+	err := ws.Request("fee", nil, result)
+	return result, err
+}
+
+// ServerInfo returns a server's status, including load factor.
+func (ws *Websocket) ServerInfo() (*ServerInfo, error) {
+	result := &ServerInfoResult{}
+	err := ws.Request("server_info", nil, result)
+	if err != nil {
+		return nil, err
+	}
+	return &result.Info, nil
+}
+
+// drops parses a fee level, which rippled encodes as string.
+func drops(n json.Number) (int, error) {
+	i, err := strconv.ParseInt(n.String(), 10, 64)
+	return int(i), err
+}
+
+// BaseFee is the cost of a reference transaction, absent load.
+func (fee *FeeResult) BaseFee() (int, error) { return drops(fee.Drops.BaseFee) }
+
+// MinimumFee is the cost for a reference transaction to be queued.
+func (fee *FeeResult) MinimumFee() (int, error) { return drops(fee.Drops.MinimumFee) }
+
+// OpenLedgerFee is the cost for a reference transaction to be included
+// in the current open ledger.
+func (fee *FeeResult) OpenLedgerFee() (int, error) { return drops(fee.Drops.OpenLedgerFee) }
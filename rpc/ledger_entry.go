@@ -0,0 +1,83 @@
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/rubblelabs/ripple/data"
+)
+
+// {
+//   "command": "ledger_entry",
+//   "payment_channel": "C7F634794B79DB40E87179A9D1BF05D05797AE7E92DF8E93FD6656E8C4BE3AE7",
+//   "ledger_index": "validated"
+// }
+type LedgerEntryParams struct {
+	PaymentChannel string      `json:"payment_channel,omitempty"`
+	Check          string      `json:"check,omitempty"`
+	LedgerIndex    interface{} `json:"ledger_index,omitempty"`
+}
+
+type LedgerEntryResult struct {
+	Index       string          `json:"index"`
+	LedgerIndex uint32          `json:"ledger_index"`
+	Node        json.RawMessage `json:"node"`
+	Validated   bool            `json:"validated"`
+}
+
+// PaymentChannel is a channel's ledger object.  Amount is the total
+// XRP set aside for the channel, Balance how much of it has been paid
+// to the destination.  Index is the channel's ID.
+type PaymentChannel struct {
+	LedgerEntryType string
+	Index           data.Hash256 `json:"index"`
+	Account         data.Account
+	Destination     data.Account
+	Amount          data.Amount
+	Balance         data.Amount
+	PublicKey       data.PublicKey
+	SettleDelay     uint32
+	Expiration      *uint32 `json:",omitempty"`
+	CancelAfter     *uint32 `json:",omitempty"`
+	SourceTag       *uint32 `json:",omitempty"`
+	DestinationTag  *uint32 `json:",omitempty"`
+}
+
+// PaymentChannel returns a channel, from the most recent validated
+// ledger.
+func (ws *Websocket) PaymentChannel(id data.Hash256) (*PaymentChannel, error) {
+	params := LedgerEntryParams{
+		PaymentChannel: id.String(),
+		LedgerIndex:    "validated",
+	}
+	var result LedgerEntryResult
+	err := ws.Request("ledger_entry", params, &result)
+	if err != nil {
+		return nil, err
+	}
+	channel := &PaymentChannel{}
+	err = json.Unmarshal(result.Node, channel)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode payment channel %s: %w", id, err)
+	}
+	channel.Index = id // "index" is outside of "node"
+	return channel, nil
+}
+
+// PaymentChannels returns the channels in an account's owner
+// directory.  That is, channels it funds and, on networks with the
+// fixPayChanRecipientOwnerDir amendment, those paying it.
+func (ws *Websocket) PaymentChannels(account data.Account) ([]PaymentChannel, error) {
+	objects, err := ws.AccountObjects(account, "payment_channel")
+	if err != nil {
+		return nil, err
+	}
+	channels := make([]PaymentChannel, len(objects))
+	for i, obj := range objects {
+		err = json.Unmarshal(obj, &channels[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return channels, nil
+}
@@ -1,8 +1,95 @@
 package rpc
 
-import "github.com/rubblelabs/ripple/data"
+import (
+	"github.com/pkg/errors"
+	"github.com/rubblelabs/ripple/data"
+)
 
+// {
+//   "command": "ripple_path_find",
+//   "source_account": "r9cZA1mLK5R5Am25ArfXFmqgNwjZgnfk59",
+//   "source_currencies": [{"currency": "XRP"}, {"currency": "USD"}],
+//   "destination_account": "r9cZA1mLK5R5Am25ArfXFmqgNwjZgnfk59",
+//   "destination_amount": {
+//     "value": "0.001",
+//     "currency": "USD",
+//     "issuer": "rvYAfWj5gh67oV6fW32ZzP3Aw4Eubs59B"
+//   }
+// }
 type PathFind struct {
-	Destination_account string      `json:"destination_account"`
-	Destination_amount  data.Amount `json:"destination_amount"`
+	Source_account      string         `json:"source_account"`
+	Source_currencies   []PathCurrency `json:"source_currencies,omitempty"`
+	Destination_account string         `json:"destination_account"`
+	Destination_amount  data.Amount    `json:"destination_amount"`
+}
+
+// PathCurrency is a currency the source account may spend.  Without
+// issuer, any issuer of the currency may be used.
+type PathCurrency struct {
+	Currency string `json:"currency"`
+	Issuer   string `json:"issuer,omitempty"`
+}
+
+type PathFindResult struct {
+	Alternatives           []PathAlternative `json:"alternatives"`
+	Destination_account    string            `json:"destination_account"`
+	Destination_currencies []string          `json:"destination_currencies"`
+}
+
+// PathAlternative is one way to deliver the destination amount,
+// costing the source amount (an estimate).
+type PathAlternative struct {
+	Paths_computed [][]PathStep `json:"paths_computed"`
+	Source_amount  data.Amount  `json:"source_amount"`
+}
+
+// {"account": "rvYAfWj5gh67oV6fW32ZzP3Aw4Eubs59B", "type": 1, "type_hex": "0000000000000001"}
+// {"currency": "USD", "issuer": "rvYAfWj5gh67oV6fW32ZzP3Aw4Eubs59B", "type": 48, "type_hex": "0000000000000030"}
+type PathStep struct {
+	Account  string `json:"account,omitempty"`
+	Currency string `json:"currency,omitempty"`
+	Issuer   string `json:"issuer,omitempty"`
+}
+
+// PathSet converts computed paths, for the Paths field of a Payment.
+func (alt PathAlternative) PathSet() (data.PathSet, error) {
+	var paths data.PathSet
+	for _, computed := range alt.Paths_computed {
+		var path data.Path
+		for _, step := range computed {
+			var elem data.PathElem
+			var err error
+			if step.Account != "" {
+				elem.Account, err = data.NewAccountFromAddress(step.Account)
+				if err != nil {
+					return nil, errors.Wrapf(err, "Bad path account %q", step.Account)
+				}
+			}
+			if step.Currency != "" {
+				currency, err := data.NewCurrency(step.Currency)
+				if err != nil {
+					return nil, errors.Wrapf(err, "Bad path currency %q", step.Currency)
+				}
+				elem.Currency = &currency
+			}
+			if step.Issuer != "" {
+				elem.Issuer, err = data.NewAccountFromAddress(step.Issuer)
+				if err != nil {
+					return nil, errors.Wrapf(err, "Bad path issuer %q", step.Issuer)
+				}
+			}
+			path = append(path, elem)
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// RipplePathFind returns alternative ways, if any, to deliver an
+// amount.  Alternatives are computed against the current open
+// ledger.
+func (ws *Websocket) RipplePathFind(params PathFind) (*PathFindResult, error) {
+	result := &PathFindResult{}
+	err := ws.Request("ripple_path_find", params, result)
+	return result, err
 }
@@ -0,0 +1,115 @@
+package rpc
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+)
+
+// Websocket makes requests not supported by rubblelabs'
+// websockets.Remote, for instance account_objects.  Requests are
+// made one at a time; messages other than the response (i.e. from
+// subscriptions) are discarded.
+type Websocket struct {
+	url  string
+	conn *websocket.Conn
+	id   uint64
+	mu   sync.Mutex
+}
+
+// websocket response envelope
+type wsResponse struct {
+	ID     uint64          `json:"id"`
+	Type   string          `json:"type"`
+	Status string          `json:"status"`
+	Result json.RawMessage `json:"result"`
+
+	// Present only when error:
+	Error        string `json:"error"`
+	ErrorCode    int    `json:"error_code"`
+	ErrorMessage string `json:"error_message"`
+}
+
+func NewWebsocket(url string) (*Websocket, error) {
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to connect to %s", url)
+	}
+	return &Websocket{url: url, conn: conn}, nil
+}
+
+func (ws *Websocket) String() string {
+	return fmt.Sprintf("websocket via %s", ws.url)
+}
+
+func (ws *Websocket) Close() error {
+	return ws.conn.Close()
+}
+
+// Request sends a command, with parameters, and decodes the result
+// into target.  Params must encode to a JSON object (or be nil).
+func (ws *Websocket) Request(command string, params interface{}, target interface{}) error {
+	request := make(map[string]interface{})
+	if params != nil {
+		b, err := json.Marshal(params)
+		if err != nil {
+			return err
+		}
+		err = json.Unmarshal(b, &request)
+		if err != nil {
+			return errors.Wrapf(err, "Params of %s must be JSON object", command)
+		}
+	}
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	ws.id++
+	request["id"] = ws.id
+	request["command"] = command
+
+	err := ws.conn.WriteJSON(request)
+	if err != nil {
+		return errors.Wrapf(err, "Failed to send %s", command)
+	}
+
+	for {
+		var response wsResponse
+		err = ws.conn.ReadJSON(&response)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to read %s response", command)
+		}
+		if response.Type != "response" || response.ID != ws.id {
+			continue // not the response we're waiting for
+		}
+		if response.Status != "success" {
+			return &WebsocketError{
+				Command: command,
+				Code:    response.Error,
+				Message: response.ErrorMessage,
+			}
+		}
+		if target == nil {
+			return nil
+		}
+		return json.Unmarshal(response.Result, target)
+	}
+}
+
+// WebsocketError is returned when rippled responds with an error,
+// for example "actNotFound".
+type WebsocketError struct {
+	Command string
+	Code    string
+	Message string
+}
+
+func (e *WebsocketError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%s failed: %s", e.Command, e.Code)
+	}
+	return fmt.Sprintf("%s failed: %s (%s)", e.Command, e.Code, e.Message)
+}
@@ -1,10 +1,53 @@
 package tx
 
 import (
+	"crypto/md5"
+	"encoding/hex"
+	"math/big"
+	"strings"
+
 	"github.com/pkg/errors"
 	"github.com/rubblelabs/ripple/data"
 )
 
+// AccountSet flags, see https://xrpl.org/accountset.html#accountset-flags
+const (
+	AsfRequireDest   uint32 = 1
+	AsfRequireAuth   uint32 = 2
+	AsfDisallowXRP   uint32 = 3
+	AsfDisableMaster uint32 = 4
+	AsfAccountTxnID  uint32 = 5
+	AsfNoFreeze      uint32 = 6
+	AsfGlobalFreeze  uint32 = 7
+	AsfDefaultRipple uint32 = 8
+	AsfDepositAuth   uint32 = 9
+)
+
+// AccountSetFlag maps lower case names to AccountSet flags.
+var AccountSetFlag = map[string]uint32{
+	"requiredest":   AsfRequireDest,
+	"requireauth":   AsfRequireAuth,
+	"disallowxrp":   AsfDisallowXRP,
+	"disablemaster": AsfDisableMaster,
+	"accounttxnid":  AsfAccountTxnID,
+	"nofreeze":      AsfNoFreeze,
+	"globalfreeze":  AsfGlobalFreeze,
+	"defaultripple": AsfDefaultRipple,
+	"depositauth":   AsfDepositAuth,
+}
+
+// AccountSetWarning explains AccountSet flags which deserve a second
+// thought before they are set.
+var AccountSetWarning = map[uint32]string{
+	AsfNoFreeze:      "NoFreeze is permanent.  Once set, it cannot be cleared, and the account can never again freeze trust lines.",
+	AsfDisableMaster: "DisableMaster prevents the master key from signing for the account.",
+	AsfGlobalFreeze:  "GlobalFreeze freezes all trust lines holding the account's issuances.",
+	AsfRequireAuth:   "RequireAuth requires the account to authorize each trust line to its issuances.",
+}
+
+// Transfer rate is expressed in billionths; 1000000000 means no fee.
+const transferRateUnity = 1000000000
+
 func NewAccountSet(options ...func(data.Transaction) error) (*data.AccountSet, error) {
 	tx := &data.AccountSet{TxBase: data.TxBase{TransactionType: data.ACCOUNT_SET}}
 	err := Prepare(tx, options...)
@@ -22,6 +65,109 @@ func SetAccountFlag(flag uint32) func(data.Transaction) error {
 	}
 }
 
+func ClearAccountFlag(flag uint32) func(data.Transaction) error {
+	return func(tx data.Transaction) error {
+		t, ok := tx.(*data.AccountSet)
+		if !ok {
+			return errors.Errorf("Expected AccountSet transaction, got %s", tx.GetBase().TransactionType)
+		}
+		t.ClearFlag = &flag
+		return nil
+	}
+}
+
+// TransferRate converts a percent, i.e. "0.2" for a 0.2% fee, into
+// the TransferRate field of an AccountSet.  A fee of 0% removes the
+// transfer rate.  The network permits fees from 0% to 100%.
+func TransferRate(percent string) (uint32, error) {
+	pct, ok := new(big.Rat).SetString(percent)
+	if !ok {
+		return 0, errors.Errorf("Bad transfer fee %q, expected percent", percent)
+	}
+	if pct.Sign() < 0 || pct.Cmp(big.NewRat(100, 1)) > 0 {
+		return 0, errors.Errorf("Transfer fee %s%% out of range (0%% to 100%%)", percent)
+	}
+	if pct.Sign() == 0 {
+		return 0, nil
+	}
+
+	// rate = unity * (1 + pct/100)
+	rate := new(big.Rat).Mul(pct, big.NewRat(transferRateUnity/100, 1))
+	rate.Add(rate, big.NewRat(transferRateUnity, 1))
+	if !rate.IsInt() {
+		return 0, errors.Errorf("Transfer fee %s%% is too precise (at most 7 decimal places)", percent)
+	}
+	return uint32(rate.Num().Uint64()), nil
+}
+
+// SetTransferRate sets the fee charged when others transfer an
+// account's issuances.  Nil for no change; use 0 to remove the fee.
+func SetTransferRate(rate *uint32) func(data.Transaction) error {
+	return func(tx data.Transaction) error {
+		t, ok := tx.(*data.AccountSet)
+		if !ok {
+			return errors.Errorf("Expected AccountSet transaction, got %s", tx.GetBase().TransactionType)
+		}
+		if rate == nil {
+			return nil
+		}
+		if *rate != 0 && (*rate < transferRateUnity || *rate > 2*transferRateUnity) {
+			return errors.Errorf("Bad transfer rate %d", *rate)
+		}
+		t.TransferRate = rate
+		return nil
+	}
+}
+
+// SetTickSize sets significant digits of exchange rates of offers
+// involving an account's issuances.  Valid sizes are 3 to 15, or 0 to
+// remove.  Nil for no change.
+func SetTickSize(size *uint8) func(data.Transaction) error {
+	return func(tx data.Transaction) error {
+		t, ok := tx.(*data.AccountSet)
+		if !ok {
+			return errors.Errorf("Expected AccountSet transaction, got %s", tx.GetBase().TransactionType)
+		}
+		if size == nil {
+			return nil
+		}
+		if *size != 0 && (*size < 3 || *size > 15) {
+			return errors.Errorf("Bad tick size %d, expected 3 to 15 (or 0 to remove)", *size)
+		}
+		t.TickSize = size
+		return nil
+	}
+}
+
+// SetEmailHash sets the hash of an email address, used for avatar
+// (gravatar) lookups.  Accepts an email address, which is hashed
+// (md5, after trimming and lower case), or 32 hexidecimal digits.
+// Empty string removes the email hash.  Nil for no change.
+func SetEmailHash(email *string) func(data.Transaction) error {
+	return func(tx data.Transaction) error {
+		t, ok := tx.(*data.AccountSet)
+		if !ok {
+			return errors.Errorf("Expected AccountSet transaction, got %s", tx.GetBase().TransactionType)
+		}
+		if email == nil {
+			return nil
+		}
+
+		var hash data.Hash128
+		if strings.Contains(*email, "@") {
+			hash = data.Hash128(md5.Sum([]byte(strings.ToLower(strings.TrimSpace(*email)))))
+		} else if *email != "" {
+			b, err := hex.DecodeString(*email)
+			if err != nil || len(b) != len(hash) {
+				return errors.Errorf("Bad email hash %q, expected email address or %d hexidecimal digits", *email, 2*len(hash))
+			}
+			copy(hash[:], b)
+		}
+		t.EmailHash = &hash
+		return nil
+	}
+}
+
 func SetDomain(domain *string) func(data.Transaction) error {
 	return func(tx data.Transaction) error {
 		t, ok := tx.(*data.AccountSet)
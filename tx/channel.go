@@ -17,48 +17,6 @@ func NewPaymentChannelCreate(options ...func(data.Transaction) error) (*data.Pay
 
 }
 
-// Deprecated, use SetDestination()
-func SetChannelDestinationXXX(account data.Account) func(data.Transaction) error {
-	return func(tx data.Transaction) error {
-		t, ok := tx.(*data.PaymentChannelCreate)
-		if !ok {
-			return errors.Errorf("Expected PaymentChannelCreate transaction, got %s", tx.GetBase().TransactionType)
-		}
-
-		t.Destination = account
-		return nil
-	}
-}
-
-// Deprecated, use SetDestinationTag()
-func SetChannelDestinationTagXXX(tag *uint32) func(data.Transaction) error {
-	return func(tx data.Transaction) error {
-		t, ok := tx.(*data.PaymentChannelCreate)
-		if !ok {
-			return errors.Errorf("Expected PaymentChannelCreate transaction, got %s", tx.GetBase().TransactionType)
-		}
-
-		t.DestinationTag = tag
-		return nil
-	}
-}
-
-// Deprecated, use SetAmount()
-func SetChannelAmountXXX(amountStr string) func(data.Transaction) error {
-	return func(tx data.Transaction) error {
-		t, ok := tx.(*data.PaymentChannelCreate)
-		if !ok {
-			return errors.Errorf("Expected PaymentChannelCreate transaction, got %s", tx.GetBase().TransactionType)
-		}
-		amount, err := data.NewAmount(amountStr)
-		if err != nil || !amount.IsNative() { // channels support only XRP.
-			return errors.Wrapf(err, "Invalid amount: %s", amountStr)
-		}
-		t.Amount = *amount
-		return nil
-	}
-}
-
 func SetChannelPublicKey(key data.PublicKey) func(data.Transaction) error {
 	return func(tx data.Transaction) error {
 		t, ok := tx.(*data.PaymentChannelCreate)
@@ -104,47 +62,94 @@ func NewPaymentChannelClaim(options ...func(data.Transaction) error) (*data.Paym
 
 }
 
+// SetChannel identifies the channel to fund or claim from.
+func SetChannel(id data.Hash256) func(data.Transaction) error {
+	return func(tx data.Transaction) error {
+		switch t := tx.(type) {
+		default:
+			return errors.Errorf("Expected PaymentChannelFund or PaymentChannelClaim transaction, got %s", tx.GetBase().TransactionType)
+		case *data.PaymentChannelFund:
+			t.Channel = id
+		case *data.PaymentChannelClaim:
+			t.Channel = id
+		}
+		return nil
+	}
+}
+
+// Deprecated, use SetChannel()
 func SetClaimChannel(id data.Hash256) func(data.Transaction) error {
+	return SetChannel(id)
+}
+
+func SetClaimAmount(amt interface{}) func(data.Transaction) error {
 	return func(tx data.Transaction) error {
 		t, ok := tx.(*data.PaymentChannelClaim)
 		if !ok {
 			return errors.Errorf("Expected PaymentChannelClaim transaction, got %s", tx.GetBase().TransactionType)
 		}
-		t.Channel = id
+		amount, err := claimAmount(amt)
+		if err != nil {
+			return err
+		}
+		t.Amount = amount
 		return nil
 	}
 }
 
-func SetClaimAmount(amountStr string) func(data.Transaction) error {
+func SetClaimBalance(amt interface{}) func(data.Transaction) error {
 	return func(tx data.Transaction) error {
 		t, ok := tx.(*data.PaymentChannelClaim)
 		if !ok {
 			return errors.Errorf("Expected PaymentChannelClaim transaction, got %s", tx.GetBase().TransactionType)
 		}
-		amount, err := data.NewAmount(amountStr)
-		if err != nil || !amount.IsNative() { // channels support only XRP.
-			return errors.Wrapf(err, "Invalid amount: %s", amountStr)
+		amount, err := claimAmount(amt)
+		if err != nil {
+			return err
 		}
-		t.Amount = amount
+		t.Balance = amount
 		return nil
 	}
 }
 
-func SetClaimBalance(amountStr string) func(data.Transaction) error {
+// SetClaimSignature adds a claim signed by the channel's key, for the
+// destination to receive the claimed amount.
+func SetClaimSignature(signature []byte, key data.PublicKey) func(data.Transaction) error {
 	return func(tx data.Transaction) error {
 		t, ok := tx.(*data.PaymentChannelClaim)
 		if !ok {
 			return errors.Errorf("Expected PaymentChannelClaim transaction, got %s", tx.GetBase().TransactionType)
 		}
-		amount, err := data.NewAmount(amountStr)
-		if err != nil || !amount.IsNative() { // channels support only XRP.
-			return errors.Wrapf(err, "Invalid amount: %s", amountStr)
-		}
-		t.Balance = amount
+		sig := data.VariableLength(signature)
+		t.Signature = &sig
+		t.PublicKey = &key
 		return nil
 	}
 }
 
+// claimAmount accepts XRP amount as string or data.Amount.
+func claimAmount(amt interface{}) (*data.Amount, error) {
+	var amount *data.Amount
+	var err error
+	switch amt := amt.(type) {
+	default:
+		return nil, errors.Errorf("Unexpected amount type %T", amt)
+	case string:
+		amount, err = data.NewAmount(amt)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Invalid amount: %s", amt)
+		}
+	case *data.Amount:
+		amount = amt
+	case data.Amount:
+		amount = &amt
+	}
+	if !amount.IsNative() { // channels support only XRP.
+		return nil, errors.Errorf("Invalid amount (non-XRP): %s", amount)
+	}
+	return amount, nil
+}
+
 func SetClaimClose(value bool) func(data.Transaction) error {
 	return func(tx data.Transaction) error {
 		t, ok := tx.(*data.PaymentChannelClaim)
@@ -46,10 +46,14 @@ func SetExpiration(rippleTime uint32) func(data.Transaction) error {
 	return func(tx data.Transaction) error {
 		switch tx := tx.(type) {
 		default:
-			return fmt.Errorf("Unexpected transaction type %T in SetCancelAfter()", tx)
+			return fmt.Errorf("Unexpected transaction type %T in SetExpiration()", tx)
 
 		case *data.CheckCreate:
 			tx.Expiration = &rippleTime
+		case *data.PaymentChannelFund:
+			tx.Expiration = &rippleTime
+		case *data.OfferCreate:
+			tx.Expiration = &rippleTime
 
 		}
 		return nil
@@ -7,24 +7,45 @@ import (
 	"github.com/rubblelabs/ripple/data"
 )
 
-func NewEscrowCreate(options ...func(data.Transaction) error) (*data.EscrowCreate, error) {
-	tx := &data.EscrowCreate{
-		TxBase: data.TxBase{
-			TransactionType: data.ESCROW_CREATE,
-		},
+// EscrowCreate adds the Condition field, which rubblelabs'
+// EscrowCreate lacks.  Encoded, with data.Raw or as JSON, it is the
+// EscrowCreate rippled expects.
+type EscrowCreate struct {
+	data.EscrowCreate
+	Condition *data.VariableLength `json:",omitempty"`
+}
+
+// EscrowFinish adds the Condition and Fulfillment fields, which
+// rubblelabs' EscrowFinish lacks.
+type EscrowFinish struct {
+	data.EscrowFinish
+	Condition   *data.VariableLength `json:",omitempty"`
+	Fulfillment *data.VariableLength `json:",omitempty"`
+}
+
+// NewEscrow returns an empty escrow transaction of type typ, either
+// the EscrowCreate or EscrowFinish here, or nil for other types.
+// Decoders use it in place of rubblelabs' types.
+func NewEscrow(typ data.TransactionType) data.Transaction {
+	switch typ {
+	case data.ESCROW_CREATE:
+		return &EscrowCreate{EscrowCreate: data.EscrowCreate{TxBase: data.TxBase{TransactionType: typ}}}
+	case data.ESCROW_FINISH:
+		return &EscrowFinish{EscrowFinish: data.EscrowFinish{TxBase: data.TxBase{TransactionType: typ}}}
 	}
+	return nil
+}
+
+func NewEscrowCreate(options ...func(data.Transaction) error) (*EscrowCreate, error) {
+	tx := NewEscrow(data.ESCROW_CREATE).(*EscrowCreate)
 	err := Prepare(tx, options...)
 
 	return tx, err
 
 }
 
-func NewEscrowFinish(options ...func(data.Transaction) error) (*data.EscrowFinish, error) {
-	tx := &data.EscrowFinish{
-		TxBase: data.TxBase{
-			TransactionType: data.ESCROW_FINISH,
-		},
-	}
+func NewEscrowFinish(options ...func(data.Transaction) error) (*EscrowFinish, error) {
+	tx := NewEscrow(data.ESCROW_FINISH).(*EscrowFinish)
 	err := Prepare(tx, options...)
 
 	return tx, err
@@ -47,9 +68,9 @@ func SetFinishAfter(rippleTime uint32) func(data.Transaction) error {
 	return func(tx data.Transaction) error {
 		switch tx := tx.(type) {
 		default:
-			return fmt.Errorf("Unexpected transaction type %T in SetCancelAfter()", tx)
+			return fmt.Errorf("Unexpected transaction type %T in SetFinishAfter()", tx)
 
-		case *data.EscrowCreate:
+		case *EscrowCreate:
 			tx.FinishAfter = &rippleTime
 
 		}
@@ -83,7 +104,7 @@ func SetOwner(address interface{}) func(data.Transaction) error {
 		default:
 			return fmt.Errorf("Unexpected transaction type %T in SetOwner()", tx)
 
-		case *data.EscrowFinish:
+		case *EscrowFinish:
 			tx.Owner = *account
 		case *data.EscrowCancel:
 			tx.Owner = *account
@@ -93,3 +114,39 @@ func SetOwner(address interface{}) func(data.Transaction) error {
 		return nil
 	}
 }
+
+// SetCondition sets the DER encoded crypto-condition of an escrow.
+// EscrowFinish must include the same condition as EscrowCreate.
+func SetCondition(condition []byte) func(data.Transaction) error {
+	return func(tx data.Transaction) error {
+		c := data.VariableLength(condition)
+		switch tx := tx.(type) {
+		default:
+			return fmt.Errorf("Unexpected transaction type %T in SetCondition()", tx)
+
+		case *EscrowCreate:
+			tx.Condition = &c
+		case *EscrowFinish:
+			tx.Condition = &c
+
+		}
+		return nil
+	}
+}
+
+// SetFulfillment sets the DER encoded fulfillment of a conditional
+// escrow.
+func SetFulfillment(fulfillment []byte) func(data.Transaction) error {
+	return func(tx data.Transaction) error {
+		f := data.VariableLength(fulfillment)
+		switch tx := tx.(type) {
+		default:
+			return fmt.Errorf("Unexpected transaction type %T in SetFulfillment()", tx)
+
+		case *EscrowFinish:
+			tx.Fulfillment = &f
+
+		}
+		return nil
+	}
+}
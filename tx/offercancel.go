@@ -42,7 +42,7 @@ func SetOfferSequence(offerSeq interface{}) func(data.Transaction) error {
 			tx.OfferSequence = os
 		case *data.OfferCreate:
 			tx.OfferSequence = &os // optional in OfferCreate
-		case *data.EscrowFinish:
+		case *EscrowFinish:
 			tx.OfferSequence = os
 		case *data.EscrowCancel:
 			tx.OfferSequence = os
@@ -20,23 +20,31 @@ func NewPayment(options ...func(data.Transaction) error) (*data.Payment, error)
 
 func SetInvoiceID(id interface{}) func(data.Transaction) error {
 	return func(tx data.Transaction) error {
-		t, ok := tx.(*data.Payment)
-		if !ok {
-			return errors.Errorf("Expected Payment transaction, got %s", tx.GetBase().TransactionType)
-		}
+		var invoiceID *data.Hash256
 		var err error
 		switch v := id.(type) {
 		case string:
-			t.InvoiceID, err = data.NewHash256(v)
+			invoiceID, err = data.NewHash256(v)
+			if err != nil {
+				return err
+			}
 		case data.Hash256:
-			t.InvoiceID = &v
+			invoiceID = &v
 		case *data.Hash256:
-			t.InvoiceID = v
+			invoiceID = v
 		default:
 			return fmt.Errorf("SetInvoiceID: Wrong type %+v", v)
 		}
 
-		return err
+		switch t := tx.(type) {
+		default:
+			return errors.Errorf("Expected Payment or CheckCreate transaction, got %s", tx.GetBase().TransactionType)
+		case *data.Payment:
+			t.InvoiceID = invoiceID
+		case *data.CheckCreate:
+			t.InvoiceID = invoiceID
+		}
+		return nil
 	}
 }
 
@@ -89,6 +97,25 @@ func SetDeliverMin(amt interface{}) func(data.Transaction) error {
 			return fmt.Errorf("Unexpected transaction type %T in SetDeliverMin()", tx)
 		case *data.Payment:
 			tx.DeliverMin = amount
+		case *data.CheckCash:
+			tx.DeliverMin = amount
+		}
+		return nil
+	}
+}
+
+// SetPaths sets the paths of a cross-currency Payment, i.e. as
+// computed by ripple_path_find.  Empty paths are omitted.
+func SetPaths(paths data.PathSet) func(data.Transaction) error {
+	return func(tx data.Transaction) error {
+		payment, ok := tx.(*data.Payment)
+		if !ok {
+			return errors.Errorf("Expected Payment transaction, got %s", tx.GetBase().TransactionType)
+		}
+		if len(paths) == 0 {
+			payment.Paths = nil
+		} else {
+			payment.Paths = &paths
 		}
 		return nil
 	}
@@ -0,0 +1,38 @@
+package tx
+
+import (
+	"github.com/pkg/errors"
+	"github.com/rubblelabs/ripple/data"
+)
+
+func NewSetRegularKey(options ...func(data.Transaction) error) (*data.SetRegularKey, error) {
+	tx := &data.SetRegularKey{
+		TxBase: data.TxBase{
+			TransactionType: data.SET_REGULAR_KEY,
+		},
+	}
+	err := Prepare(tx, options...)
+	return tx, err
+}
+
+// SetRegularKey authorizes a key pair, identified by address, to sign
+// for an account.  When regularKey is nil, the transaction removes the
+// account's regular key.
+func SetRegularKey(regularKey *data.Account) func(data.Transaction) error {
+	return func(tx data.Transaction) error {
+		t, ok := tx.(*data.SetRegularKey)
+		if !ok {
+			return errors.Errorf("Expected SetRegularKey transaction, got %s", tx.GetBase().TransactionType)
+		}
+		if regularKey == nil {
+			t.RegularKey = nil
+			return nil
+		}
+		if *regularKey == t.Account {
+			return errors.Errorf("Regular key must differ from account %s (its master key)", t.Account)
+		}
+		rk := data.RegularKey(*regularKey)
+		t.RegularKey = &rk
+		return nil
+	}
+}
@@ -0,0 +1,89 @@
+package tx
+
+import (
+	"github.com/pkg/errors"
+	"github.com/rubblelabs/ripple/data"
+)
+
+// Maximum number of entries in a signer list.
+const MaxSignerEntries = 8
+
+// NewSignerListSet composes a transaction to create, replace, or
+// (with quorum 0 and no entries) delete an account's signer list.
+func NewSignerListSet(options ...func(data.Transaction) error) (*data.SignerListSet, error) {
+	tx := &data.SignerListSet{
+		TxBase: data.TxBase{
+			TransactionType: data.SIGNER_LIST_SET,
+		},
+	}
+	err := Prepare(tx, options...)
+	if err != nil {
+		return tx, err
+	}
+	return tx, ValidateSignerList(tx)
+}
+
+func SetSignerQuorum(quorum uint32) func(data.Transaction) error {
+	return func(tx data.Transaction) error {
+		t, ok := tx.(*data.SignerListSet)
+		if !ok {
+			return errors.Errorf("Expected SignerListSet transaction, got %s", tx.GetBase().TransactionType)
+		}
+		t.SignerQuorum = quorum
+		return nil
+	}
+}
+
+// AddSignerEntry adds an account, with weight, to the signer list.
+func AddSignerEntry(account data.Account, weight uint16) func(data.Transaction) error {
+	return func(tx data.Transaction) error {
+		t, ok := tx.(*data.SignerListSet)
+		if !ok {
+			return errors.Errorf("Expected SignerListSet transaction, got %s", tx.GetBase().TransactionType)
+		}
+		if weight == 0 {
+			return errors.Errorf("Signer %s weight must be positive", account)
+		}
+		t.SignerEntries = append(t.SignerEntries, data.SignerEntry{
+			Account:      &account,
+			SignerWeight: &weight,
+		})
+		return nil
+	}
+}
+
+// ValidateSignerList checks the rules enforced by the network, so that
+// a bad signer list is detected before it is signed and submitted.
+func ValidateSignerList(t *data.SignerListSet) error {
+	if t.SignerQuorum == 0 {
+		if len(t.SignerEntries) > 0 {
+			return errors.New("Signer list with entries requires quorum (quorum 0 deletes the signer list)")
+		}
+		return nil // delete
+	}
+
+	if len(t.SignerEntries) == 0 {
+		return errors.New("Signer list requires at least one signer")
+	}
+	if len(t.SignerEntries) > MaxSignerEntries {
+		return errors.Errorf("Signer list has %d entries, maximum is %d", len(t.SignerEntries), MaxSignerEntries)
+	}
+
+	seen := make(map[data.Account]bool)
+	var total uint32
+	for _, entry := range t.SignerEntries {
+		account := *entry.Account
+		if account == t.Account {
+			return errors.Errorf("Account %s cannot be in its own signer list", account)
+		}
+		if seen[account] {
+			return errors.Errorf("Signer %s appears more than once", account)
+		}
+		seen[account] = true
+		total += uint32(*entry.SignerWeight)
+	}
+	if total < t.SignerQuorum {
+		return errors.Errorf("Quorum %d is unreachable, signer weights total %d", t.SignerQuorum, total)
+	}
+	return nil
+}
@@ -174,7 +174,7 @@ func SetDestination(account data.Account) func(data.Transaction) error {
 			tx.Destination = account
 		case *data.Payment:
 			tx.Destination = account
-		case *data.EscrowCreate:
+		case *EscrowCreate:
 			tx.Destination = account
 		case *data.CheckCreate:
 			tx.Destination = account
@@ -192,7 +192,7 @@ func SetDestinationTag(tag *uint32) func(data.Transaction) error {
 			tx.DestinationTag = tag
 		case *data.Payment:
 			tx.DestinationTag = tag
-		case *data.EscrowCreate:
+		case *EscrowCreate:
 			tx.DestinationTag = tag
 		case *data.CheckCreate:
 			tx.DestinationTag = tag
@@ -237,13 +237,19 @@ func SetAmount(amt interface{}) func(data.Transaction) error {
 
 		case *data.PaymentChannelCreate:
 			if !amount.IsNative() { // support only XRP.
-				return errors.Wrapf(err, "Invalid amount (non-XRP): %s", amt)
+				return errors.Errorf("Invalid amount (non-XRP): %s", amount)
 			}
 			tx.Amount = *amount
 
-		case *data.EscrowCreate:
+		case *data.PaymentChannelFund:
 			if !amount.IsNative() { // support only XRP.
-				return errors.Wrapf(err, "Invalid amount (non-XRP): %s", amt)
+				return errors.Errorf("Invalid amount (non-XRP): %s", amount)
+			}
+			tx.Amount = *amount
+
+		case *EscrowCreate:
+			if !amount.IsNative() { // support only XRP.
+				return errors.Errorf("Invalid amount (non-XRP): %s", amount)
 			}
 			tx.Amount = *amount
 
@@ -263,7 +269,7 @@ func SetCancelAfter(rippleTime uint32) func(data.Transaction) error {
 
 		case *data.PaymentChannelCreate:
 			tx.CancelAfter = &rippleTime
-		case *data.EscrowCreate:
+		case *EscrowCreate:
 			tx.CancelAfter = &rippleTime
 
 		}
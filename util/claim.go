@@ -0,0 +1,100 @@
+package util
+
+import (
+	"crypto/sha512"
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+	"github.com/rubblelabs/ripple/data"
+)
+
+// Hash prefix of a payment channel claim, "CLM\0".
+var claimPrefix = []byte{'C', 'L', 'M', 0}
+
+// Claim authorizes the destination of a payment channel to receive
+// up to Amount (cumulative, XRP only) from the channel.  Claims are
+// signed off-ledger, by the key named in the channel's PublicKey.
+type Claim struct {
+	Channel   data.Hash256        `json:"channel"`
+	Amount    data.Amount         `json:"amount"`
+	Signature data.VariableLength `json:"signature"`
+	PublicKey data.PublicKey      `json:"public_key"`
+}
+
+// Drops returns an XRP amount in drops.
+func Drops(amount data.Amount) (uint64, error) {
+	if amount.Value == nil || !amount.IsNative() {
+		return 0, errors.Errorf("Expected XRP amount, got %s", amount)
+	}
+	// a native value is in drops
+	drops := amount.Value.Rat()
+	if drops.Sign() < 0 {
+		return 0, errors.Errorf("Expected positive amount, got %s", amount)
+	}
+	if !drops.IsInt() {
+		return 0, errors.Errorf("Amount %s is not a whole number of drops", amount)
+	}
+	if !drops.Num().IsUint64() {
+		return 0, errors.Errorf("Amount %s overflows", amount)
+	}
+	return drops.Num().Uint64(), nil
+}
+
+// ClaimMessage returns the content signed to authorize a claim.
+func ClaimMessage(channel data.Hash256, amount data.Amount) ([]byte, error) {
+	drops, err := Drops(amount)
+	if err != nil {
+		return nil, err
+	}
+	msg := make([]byte, 0, len(claimPrefix)+len(channel)+8)
+	msg = append(msg, claimPrefix...)
+	msg = append(msg, channel[:]...)
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], drops)
+	return append(msg, b[:]...), nil
+}
+
+// SignClaim authorizes a claim on a payment channel.  The keypair must
+// match the channel's public key.
+func (pair Keypair) SignClaim(channel data.Hash256, amount data.Amount) (*Claim, error) {
+	msg, err := ClaimMessage(channel, amount)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := pair.SignMessage(msg)
+	if err != nil {
+		return nil, err
+	}
+	return &Claim{
+		Channel:   channel,
+		Amount:    amount,
+		Signature: sig,
+		PublicKey: pair.PublicKey(),
+	}, nil
+}
+
+// VerifyClaim checks a claim's signature against a channel's public
+// key.  Note the key in the claim itself is not trusted; publicKey
+// should be learned from the channel.
+func VerifyClaim(claim *Claim, publicKey data.PublicKey) error {
+	if claim.PublicKey != publicKey {
+		return errors.Errorf("Claim signed by %s, not channel key %s", claim.PublicKey, publicKey)
+	}
+	msg, err := ClaimMessage(claim.Channel, claim.Amount)
+	if err != nil {
+		return err
+	}
+	ok, err := VerifyMessage(publicKey, msg, claim.Signature)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("Bad claim signature")
+	}
+	return nil
+}
+
+func sha512Half(msg []byte) []byte {
+	sum := sha512.Sum512(msg)
+	return sum[:32]
+}
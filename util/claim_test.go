@@ -0,0 +1,35 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/rubblelabs/ripple/data"
+)
+
+func TestDrops(t *testing.T) {
+	for _, test := range []struct {
+		amount string
+		drops  uint64
+		ok     bool
+	}{
+		{"1/XRP", 1000000, true},
+		{"0.000001/XRP", 1, true},
+		{"123.456789/XRP", 123456789, true},
+		{"0/XRP", 0, true},
+		{"-1/XRP", 0, false},
+		{"1/USD/rvYAfWj5gh67oV6fW32ZzP3Aw4Eubs59B", 0, false},
+	} {
+		amount, err := data.NewAmount(test.amount)
+		if err != nil {
+			t.Fatal(err)
+		}
+		drops, err := Drops(*amount)
+		if test.ok != (err == nil) {
+			t.Errorf("%s: unexpected error %v", test.amount, err)
+			continue
+		}
+		if drops != test.drops {
+			t.Errorf("%s: wanted %d drops, got %d", test.amount, test.drops, drops)
+		}
+	}
+}
@@ -4,7 +4,9 @@ package util
 
 import (
 	"fmt"
+	"io"
 	"log"
+	"os"
 )
 
 // askForConfirmation uses Scanln to parse user input. A user must type in "yes" or "no" and
@@ -13,20 +15,31 @@ import (
 // until it gets a valid response from the user. Typically, you should use fmt to print out a question
 // before calling askForConfirmation. E.g. fmt.Println("WARNING: Are you sure? (yes/no)")
 func AskForConfirmation() bool {
-	var response string
-	_, err := fmt.Scanln(&response)
+	ok, err := AskForConfirmationFrom(os.Stdin, os.Stdout)
 	if err != nil {
 		log.Fatal(err)
 	}
+	return ok
+}
+
+// AskForConfirmationFrom is AskForConfirmation, reading the response
+// from r (i.e. a terminal, when stdin is not), and prompting again
+// on w.
+func AskForConfirmationFrom(r io.Reader, w io.Writer) (bool, error) {
+	var response string
+	_, err := fmt.Fscanln(r, &response)
+	if err != nil && err.Error() != "unexpected newline" {
+		return false, err
+	}
 	okayResponses := []string{"y", "Y", "yes", "Yes", "YES"}
 	nokayResponses := []string{"n", "N", "no", "No", "NO"}
 	if containsString(okayResponses, response) {
-		return true
+		return true, nil
 	} else if containsString(nokayResponses, response) {
-		return false
+		return false, nil
 	} else {
-		fmt.Println("Please type yes or no and then press enter:")
-		return AskForConfirmation()
+		fmt.Fprintln(w, "Please type yes or no and then press enter:")
+		return AskForConfirmationFrom(r, w)
 	}
 }
 
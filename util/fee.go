@@ -0,0 +1,88 @@
+package util
+
+import (
+	"strconv"
+
+	"github.com/dncohen/rcl/rpc"
+	"github.com/pkg/errors"
+)
+
+// Fee policies, see FeePerUnit.
+const (
+	FeeAuto = "auto"
+	FeeMin  = "min"
+)
+
+// Auto fees add a margin, since transactions may be signed and
+// submitted some time after they are composed.
+const feeMarginPercent = 20
+
+// FeeSource reports network load.  *rpc.Websocket satisfies this
+// interface.
+type FeeSource interface {
+	Fee() (*rpc.FeeResult, error)
+	ServerInfo() (*rpc.ServerInfo, error)
+}
+
+// FeeUnits returns the cost of a transaction, in fee units.  Most
+// transactions cost one unit.  A multi-signed transaction costs one
+// more per signer.  An EscrowFinish with fulfillment costs 33 units,
+// plus one per 16 bytes of fulfillment, in place of the usual one.
+func FeeUnits(signers, fulfillmentSize int) int {
+	units := 1 + signers
+	if fulfillmentSize > 0 {
+		units += 32 + fulfillmentSize/16
+	}
+	return units
+}
+
+// FeePerUnit returns the fee, in drops, of one fee unit, according to
+// policy:
+//
+//   "auto" - enough to be included in the open ledger (with margin)
+//   "min" - the minimum accepted by the network, which may be queued
+//   <drops> - a fixed number of drops
+//
+// Source may be nil, when policy is a fixed number of drops.  When
+// the `fee` command fails, load is estimated from server_info.
+func FeePerUnit(policy string, source FeeSource) (int, error) {
+	switch policy {
+	case FeeAuto, FeeMin:
+	default:
+		drops, err := strconv.Atoi(policy)
+		if err != nil || drops < 1 {
+			return 0, errors.Errorf("Expected fee %q, %q or positive number of drops, got %q", FeeAuto, FeeMin, policy)
+		}
+		return drops, nil
+	}
+	if source == nil {
+		return 0, errors.Errorf("Fee %q requires connection to network", policy)
+	}
+
+	var base, current int
+	fee, err := source.Fee()
+	if err == nil {
+		base, err = fee.MinimumFee()
+		if err == nil {
+			current, err = fee.OpenLedgerFee()
+		}
+		if err != nil {
+			return 0, errors.Wrap(err, "Failed to parse fee levels")
+		}
+	} else {
+		info, infoErr := source.ServerInfo()
+		if infoErr != nil || info.Validated_ledger == nil {
+			return 0, errors.Wrap(err, "Failed to learn network fee")
+		}
+		base = int(info.Validated_ledger.Base_fee_xrp * DropsPerXRP)
+		current = info.Fee()
+	}
+
+	if policy == FeeMin || current < base {
+		current = base
+	}
+	if policy == FeeAuto {
+		current += (current*feeMarginPercent + 99) / 100 // round up
+	}
+	return current, nil
+}
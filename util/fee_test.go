@@ -0,0 +1,112 @@
+package util
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/dncohen/rcl/rpc"
+)
+
+// fakeFees is a FeeSource.  A nil result is reported as an error.
+type fakeFees struct {
+	fee  *rpc.FeeResult
+	info *rpc.ServerInfo
+}
+
+func (f fakeFees) Fee() (*rpc.FeeResult, error) {
+	if f.fee == nil {
+		return nil, errors.New("fee unavailable")
+	}
+	return f.fee, nil
+}
+
+func (f fakeFees) ServerInfo() (*rpc.ServerInfo, error) {
+	if f.info == nil {
+		return nil, errors.New("server_info unavailable")
+	}
+	return f.info, nil
+}
+
+func feeLevels(minimum, openLedger string) fakeFees {
+	fee := &rpc.FeeResult{}
+	fee.Drops.MinimumFee = json.Number(minimum)
+	fee.Drops.OpenLedgerFee = json.Number(openLedger)
+	return fakeFees{fee: fee}
+}
+
+func serverInfo(baseFeeXRP, loadFactor float64) fakeFees {
+	return fakeFees{info: &rpc.ServerInfo{
+		Load_factor:      loadFactor,
+		Validated_ledger: &rpc.ValidatedLedger{Base_fee_xrp: baseFeeXRP},
+	}}
+}
+
+func TestFeePerUnit(t *testing.T) {
+	tests := []struct {
+		policy string
+		source FeeSource
+		want   int // 0 when error expected
+	}{
+		// explicit drops need no network
+		{"25", nil, 25},
+		{"25", feeLevels("10", "5000"), 25},
+		{"0", nil, 0},
+		{"-3", nil, 0},
+		{"lots", nil, 0},
+		{"", nil, 0},
+
+		// auto adds 20%, rounded up
+		{FeeAuto, feeLevels("10", "10"), 12},
+		{FeeAuto, feeLevels("10", "11"), 14},
+		{FeeAuto, feeLevels("10", "5000"), 6000},
+		{FeeAuto, feeLevels("10", "5"), 12}, // not below minimum
+		{FeeAuto, nil, 0},
+		{FeeAuto, feeLevels("ten", "10"), 0},
+
+		// min is the minimum, without margin
+		{FeeMin, feeLevels("10", "5000"), 10},
+		{FeeMin, nil, 0},
+
+		// server_info, when fee fails
+		{FeeAuto, serverInfo(0.00001, 2), 24},
+		{FeeMin, serverInfo(0.00001, 2), 10},
+		{FeeAuto, fakeFees{}, 0},
+		{FeeAuto, fakeFees{info: &rpc.ServerInfo{}}, 0}, // no validated ledger
+	}
+	for i, test := range tests {
+		got, err := FeePerUnit(test.policy, test.source)
+		if test.want == 0 {
+			if err == nil {
+				t.Errorf("test %d: fee %q, expected error, got %d drops", i, test.policy, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %d: fee %q: %s", i, test.policy, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("test %d: fee %q, expected %d drops, got %d", i, test.policy, test.want, got)
+		}
+	}
+}
+
+func TestFeeUnits(t *testing.T) {
+	tests := []struct {
+		signers, fulfillmentSize int
+		want                     int
+	}{
+		{0, 0, 1},
+		{3, 0, 4},                 // multi-signed, one more per signer
+		{0, 32, 35},               // 33, plus one per 16 bytes
+		{0, 40, 35},               // partial 16 bytes not counted
+		{2, 100, 3 + 32 + 100/16}, // multi-signed fulfillment
+	}
+	for _, test := range tests {
+		got := FeeUnits(test.signers, test.fulfillmentSize)
+		if got != test.want {
+			t.Errorf("%d signers, %d byte fulfillment: expected %d units, got %d", test.signers, test.fulfillmentSize, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package util
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/json"
+	"sort"
+
+	"github.com/pkg/errors"
+	"github.com/rubblelabs/ripple/crypto"
+	"github.com/rubblelabs/ripple/data"
+)
+
+// Hash prefix of a multi-signature, "SMT\0".
+var multiSignPrefix = []byte{'S', 'M', 'T', 0}
+
+// Multi-signed transactions pay the base fee for each signer, plus one.
+const BaseFeeDrops = 10
+
+// Signer is one entry of a multi-signed transaction's Signers.  The
+// rubblelabs transaction types have no Signers field, so signatures
+// are kept apart from the transaction, and joined to it by
+// MultiSigned.
+type Signer struct {
+	Signer SignerFields
+}
+
+// SignerFields is the inner object of a Signer, as rippled encodes it.
+type SignerFields struct {
+	Account       data.Account
+	SigningPubKey data.PublicKey
+	TxnSignature  data.VariableLength
+}
+
+// MultiSigned is a transaction with its Signers.  It is itself a
+// data.Transaction, which data.Raw encodes with the Signers, in
+// canonical field order.  So it may be hashed, written as a blob or
+// submitted.
+type MultiSigned struct {
+	data.Transaction
+	Signers []Signer
+}
+
+// MarshalJSON writes the transaction's fields, with Signers among
+// them, as rippled does.
+func (tx *MultiSigned) MarshalJSON() ([]byte, error) {
+	b, err := json.Marshal(tx.Transaction)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]json.RawMessage
+	err = json.Unmarshal(b, &fields)
+	if err != nil {
+		return nil, err
+	}
+	fields["Signers"], err = json.Marshal(tx.Signers)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(fields)
+}
+
+// MultiSigningMessage returns the content of a transaction signed by
+// every signer of a multi-signed transaction.  That is, the
+// transaction without Signers, and with empty SigningPubKey.
+func MultiSigningMessage(tx data.Transaction) ([]byte, error) {
+	base := tx.GetBase()
+	if base.SigningPubKey == nil {
+		base.SigningPubKey = new(data.PublicKey) // encoded empty
+	} else if !isEmptyPublicKey(base.SigningPubKey) {
+		return nil, errors.New("Multi-signed transaction must have empty SigningPubKey")
+	}
+
+	_, msg, err := data.SigningHash(tx)
+	return msg, err
+}
+
+// MultiSigningHash returns the hash signed by one signer of a
+// multi-signed transaction.
+func MultiSigningHash(tx data.Transaction, signer data.Account) (data.Hash256, []byte, error) {
+	var hash data.Hash256
+	msg, err := MultiSigningMessage(tx)
+	if err != nil {
+		return hash, nil, err
+	}
+
+	full := make([]byte, 0, len(multiSignPrefix)+len(msg)+len(signer))
+	full = append(full, multiSignPrefix...)
+	full = append(full, msg...)
+	full = append(full, signer[:]...)
+
+	sum := sha512.Sum512(full)
+	copy(hash[:], sum[:32]) // SHA512-half
+	return hash, full, nil
+}
+
+// MultiSign returns a signature for the Signers of a transaction.
+// The signer is the account listed in the transacting account's
+// signer list.  Usually this keypair belongs to signer, but it may be
+// the signer's regular key.
+func (pair Keypair) MultiSign(tx data.Transaction, signer data.Account) (Signer, error) {
+	base := tx.GetBase()
+	if base.Account == signer {
+		return Signer{}, errors.Errorf("Account %s cannot multi-sign its own transaction", signer)
+	}
+	if base.TxnSignature != nil && len(*base.TxnSignature) > 0 {
+		return Signer{}, errors.New("Transaction has a single signature, cannot multi-sign")
+	}
+	base.TxnSignature = nil
+
+	hash, msg, err := MultiSigningHash(tx, signer)
+	if err != nil {
+		return Signer{}, err
+	}
+	sig, err := crypto.Sign(pair.key.Private(pair.sequence), hash.Bytes(), msg)
+	if err != nil {
+		return Signer{}, err
+	}
+
+	return Signer{SignerFields{
+		Account:       signer,
+		SigningPubKey: pair.PublicKey(),
+		TxnSignature:  data.VariableLength(sig),
+	}}, nil
+}
+
+// AddSigners merges signatures into the Signers of a transaction.
+// The network requires Signers sorted by account, so this sorts them.
+// When an account has signed more than once, the earlier signature is
+// kept.  The transaction hash is recomputed.
+func AddSigners(tx data.Transaction, signers []Signer, signer ...Signer) ([]Signer, error) {
+	seen := make(map[data.Account]bool)
+	merged := make([]Signer, 0, len(signers)+len(signer))
+	for _, s := range append(signers[:len(signers):len(signers)], signer...) {
+		if seen[s.Signer.Account] {
+			continue
+		}
+		seen[s.Signer.Account] = true
+		merged = append(merged, s)
+	}
+	sort.Slice(merged, func(i, j int) bool {
+		return bytes.Compare(merged[i].Signer.Account[:], merged[j].Signer.Account[:]) < 0
+	})
+
+	hash, _, err := data.Raw(&MultiSigned{Transaction: tx, Signers: merged})
+	if err != nil {
+		return nil, err
+	}
+	*tx.GetHash() = hash
+	return merged, nil
+}
+
+// MultiSignFee returns the minimum fee, in drops, of a transaction
+// with the given number of signers.
+func MultiSignFee(signers int) int {
+	return BaseFeeDrops * (1 + signers)
+}
+
+func isEmptyPublicKey(pubkey *data.PublicKey) bool {
+	var empty data.PublicKey
+	return *pubkey == empty
+}
@@ -0,0 +1,141 @@
+package util
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rubblelabs/ripple/data"
+)
+
+// Names of key types, as used in configuration and on the command line.
+const (
+	KeyTypeSecp256k1 = "secp256k1"
+	KeyTypeEd25519   = "ed25519"
+)
+
+const rippleAlphabet = "rpshnaf39wBUDNEGHJKLM4PQRST7VWXYZ2bcdeCg65jkm8oFqi1tuvAxyz"
+
+// Seed encodings are distinguished by prefix.  An ed25519 seed,
+// encoded, begins "sEd".
+var (
+	secp256k1SeedPrefix = []byte{0x21}
+	ed25519SeedPrefix   = []byte{0x01, 0xE1, 0x4B}
+)
+
+// ParseKeyType accepts a key type name, i.e. "ed25519" or "secp256k1".
+func ParseKeyType(name string) (data.KeyType, error) {
+	switch strings.ToLower(name) {
+	case KeyTypeSecp256k1, "ecdsa":
+		return data.ECDSA, nil
+	case KeyTypeEd25519:
+		return data.Ed25519, nil
+	default:
+		return data.ECDSA, errors.Errorf("Unknown key type %q (expected %q or %q)", name, KeyTypeEd25519, KeyTypeSecp256k1)
+	}
+}
+
+// FormatKeyType returns the name of a key type.
+func FormatKeyType(keyType data.KeyType) string {
+	if keyType == data.Ed25519 {
+		return KeyTypeEd25519
+	}
+	return KeyTypeSecp256k1
+}
+
+// EncodeSeed returns the secret, in base58 form, for a seed.  The
+// encoding depends on the key type.
+func EncodeSeed(seed data.Seed, keyType data.KeyType) string {
+	prefix := secp256k1SeedPrefix
+	if keyType == data.Ed25519 {
+		prefix = ed25519SeedPrefix
+	}
+	b := append(append([]byte{}, prefix...), seed[:]...)
+	return base58Encode(append(b, checksum(b)...))
+}
+
+// DecodeSeed parses a secret, returning the seed and key type.
+// Secrets beginning "sEd" are ed25519, others secp256k1.  Note that
+// some tools encode ed25519 seeds without the "sEd" prefix; key type
+// of those can not be detected.
+func DecodeSeed(secret string) (data.Seed, data.KeyType, error) {
+	var seed data.Seed
+
+	b, err := base58Decode(secret)
+	if err != nil {
+		return seed, data.ECDSA, err
+	}
+	if len(b) < 4 {
+		return seed, data.ECDSA, errors.New("Bad secret (too short)")
+	}
+	payload, sum := b[:len(b)-4], b[len(b)-4:]
+	if !bytes.Equal(checksum(payload), sum) {
+		return seed, data.ECDSA, errors.New("Bad secret (checksum mismatch)")
+	}
+
+	keyType := data.ECDSA
+	switch {
+	case len(payload) == len(secp256k1SeedPrefix)+len(seed) && bytes.HasPrefix(payload, secp256k1SeedPrefix):
+		payload = payload[len(secp256k1SeedPrefix):]
+	case len(payload) == len(ed25519SeedPrefix)+len(seed) && bytes.HasPrefix(payload, ed25519SeedPrefix):
+		payload = payload[len(ed25519SeedPrefix):]
+		keyType = data.Ed25519
+	default:
+		return seed, data.ECDSA, errors.New("Bad secret (unexpected prefix or length)")
+	}
+	copy(seed[:], payload)
+	return seed, keyType, nil
+}
+
+// first four bytes of double sha256
+func checksum(b []byte) []byte {
+	first := sha256.Sum256(b)
+	second := sha256.Sum256(first[:])
+	return second[:4]
+}
+
+func base58Encode(b []byte) string {
+	x := new(big.Int).SetBytes(b)
+	radix := big.NewInt(int64(len(rippleAlphabet)))
+	mod := new(big.Int)
+
+	var out []byte
+	for x.Sign() > 0 {
+		x.DivMod(x, radix, mod)
+		out = append(out, rippleAlphabet[mod.Int64()])
+	}
+	// leading zeros
+	for _, c := range b {
+		if c != 0 {
+			break
+		}
+		out = append(out, rippleAlphabet[0])
+	}
+	// reverse
+	for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+		out[i], out[j] = out[j], out[i]
+	}
+	return string(out)
+}
+
+func base58Decode(s string) ([]byte, error) {
+	x := new(big.Int)
+	radix := big.NewInt(int64(len(rippleAlphabet)))
+	zeros := 0
+	for i, c := range s {
+		digit := strings.IndexRune(rippleAlphabet, c)
+		if digit < 0 {
+			// do not show s, which may be a secret
+			return nil, fmt.Errorf("Bad base58 character at position %d", i)
+		}
+		if digit == 0 && i == zeros {
+			zeros++
+		}
+		x.Mul(x, radix)
+		x.Add(x, big.NewInt(int64(digit)))
+	}
+	return append(make([]byte, zeros), x.Bytes()...), nil
+}
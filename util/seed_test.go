@@ -0,0 +1,80 @@
+package util
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/rubblelabs/ripple/data"
+)
+
+func TestDecodeSeed(t *testing.T) {
+	secret := "snoPBrXtMeMyMHUVTgbuqAfg1SUTb"
+	seed, keyType, err := DecodeSeed(secret)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if fmt.Sprintf("%X", seed[:]) != "DEDCE9CE67B451D852FD4E846FCDE31C" {
+		t.Errorf("wanted DEDCE9CE67B451D852FD4E846FCDE31C, got %X", seed[:])
+	}
+	if keyType != data.ECDSA {
+		t.Errorf("wanted %s, got %s", KeyTypeSecp256k1, FormatKeyType(keyType))
+	}
+	if EncodeSeed(seed, keyType) != secret {
+		t.Errorf("wanted %s, got %q", secret, EncodeSeed(seed, keyType))
+	}
+
+	// same seed, encoded for ed25519
+	ed := EncodeSeed(seed, data.Ed25519)
+	if !strings.HasPrefix(ed, "sEd") {
+		t.Errorf("wanted sEd prefix, got %q", ed)
+	}
+	edSeed, keyType, err := DecodeSeed(ed)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if edSeed != seed || keyType != data.Ed25519 {
+		t.Errorf("wanted ed25519 %X, got %s %X", seed[:], FormatKeyType(keyType), edSeed[:])
+	}
+
+	_, _, err = DecodeSeed("snoPBrXtMeMyMHUVTgbuqAfg1SUTc")
+	if err == nil {
+		t.Error("wanted checksum error")
+	}
+}
+
+func TestNewKeypair(t *testing.T) {
+	kp, err := NewKeypairFromSecret("snoPBrXtMeMyMHUVTgbuqAfg1SUTb")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if kp.Address != "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh" {
+		t.Errorf("wanted rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh, got %q", kp.Address)
+	}
+
+	// ed25519 derives a different address from the same seed
+	ed, err := NewKeypair("snoPBrXtMeMyMHUVTgbuqAfg1SUTb", data.Ed25519)
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if ed.Address == kp.Address {
+		t.Errorf("wanted ed25519 address distinct from %s", kp.Address)
+	}
+	seed, _, err := DecodeSeed("snoPBrXtMeMyMHUVTgbuqAfg1SUTb")
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	auto, err := NewKeypairFromSecret(EncodeSeed(seed, data.Ed25519))
+	if err != nil {
+		t.Error(err)
+		return
+	}
+	if auto.Address != ed.Address || auto.KeyType != data.Ed25519 {
+		t.Errorf("wanted ed25519 %s, got %s %s", ed.Address, FormatKeyType(auto.KeyType), auto.Address)
+	}
+}
@@ -3,8 +3,10 @@ package util
 import (
 	"container/heap"
 	"container/list"
+	"context"
 	"fmt"
 	"log"
+	"strings"
 	"sync"
 	"time"
 
@@ -20,6 +22,18 @@ const (
 	RecommendedLedgerInterval uint32 = 5
 )
 
+// ErrExpired indicates a transaction was not included in any ledger
+// up to its LastLedgerSequence, according to ledger history with no
+// gaps.  The outcome is final, the transaction will never succeed.
+var ErrExpired = errors.New("not validated before LastLedgerSequence")
+
+// IsTxnNotFound returns true when a tx request fails because the
+// transaction is not in the server's history (which may not be
+// complete).
+func IsTxnNotFound(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "txnNotFound")
+}
+
 type Subscription struct {
 	// Keep track of contiguous ledger history we have available.
 	min, max uint32
@@ -33,6 +47,8 @@ type Subscription struct {
 	// Make "Remote" public so that calls can made to it directly.
 	Remote *websockets.Remote
 
+	// Listeners may be added by any goroutine.
+	listenerMutex     sync.Mutex
 	sequenceListeners *ledgerSequenceWaitHeap
 	timeListeners     *ledgerTimeWaitHeap
 	txListeners       *list.List
@@ -75,6 +91,30 @@ func (sub *Subscription) connect() error {
 }
 
 func (sub *Subscription) SubmitWait(t data.Transaction) (*websockets.TxResult, error) {
+	return sub.SubmitWaitContext(context.Background(), t)
+}
+
+// SubmitWaitContext submits a transaction, then waits for its final
+// result, as WaitContext.
+func (sub *Subscription) SubmitWaitContext(ctx context.Context, t data.Transaction) (*websockets.TxResult, error) {
+	_, ledgerBeforeSubmit, err := sub.Ledgers()
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to get current ledger index.")
+	}
+
+	_, err = sub.Submit(t)
+	if err != nil {
+		return nil, err
+	}
+
+	return sub.WaitContext(ctx, t, ledgerBeforeSubmit)
+}
+
+// Submit sends a signed transaction to rippled, and returns the
+// tentative result.  A tentative result is not final, use Wait to
+// learn the validated result.  Submit may be called again, i.e. after
+// a retriable (ter) result, until LastLedgerSequence passes.
+func (sub *Subscription) Submit(t data.Transaction) (*websockets.SubmitResult, error) {
 	lastLedger := t.GetBase().LastLedgerSequence
 	if lastLedger == nil {
 		return nil, fmt.Errorf("Cannot wait for %s transaction without LastLedgerSequence.", t.GetType())
@@ -95,7 +135,6 @@ func (sub *Subscription) SubmitWait(t data.Transaction) (*websockets.TxResult, e
 	}
 
 	tentative, err := sub.Remote.Submit(t)
-	_ = tentative // TODO log tentative result?
 	if err != nil {
 		return nil, errors.Wrapf(err, "Failed to submit %s transaction.", t.GetType())
 	}
@@ -105,11 +144,40 @@ func (sub *Subscription) SubmitWait(t data.Transaction) (*websockets.TxResult, e
 	} else {
 		glog.Warningf("Tentative result of %s transaction by %s, %s: %s %s \n", t.GetType(), t.GetBase().Account, *hash, tentative.EngineResult, tentative.EngineResultMessage)
 	}
+	return tentative, nil
+}
 
-	result := <-sub.AfterTx(*hash, ledgerBeforeSubmit, *lastLedger)
+// Wait blocks until a submitted transaction is validated, or ledger
+// history shows it was not included before LastLedgerSequence.  The
+// ledger index is the last validated before the transaction was
+// first submitted.
+func (sub *Subscription) Wait(t data.Transaction, ledgerBeforeSubmit uint32) (*websockets.TxResult, error) {
+	return sub.WaitContext(context.Background(), t, ledgerBeforeSubmit)
+}
+
+// WaitContext is Wait, which returns early if the context is done.
+// The error returned wraps ErrExpired when the transaction is known
+// never to be validated, or the context's error when it is done.  In
+// the latter case the outcome is not known, the transaction may yet
+// be validated.
+func (sub *Subscription) WaitContext(ctx context.Context, t data.Transaction, ledgerBeforeSubmit uint32) (*websockets.TxResult, error) {
+	lastLedger := t.GetBase().LastLedgerSequence
+	hash := t.GetHash()
+	if lastLedger == nil || hash == nil {
+		return nil, fmt.Errorf("Cannot wait for %s transaction without LastLedgerSequence and hash.", t.GetType())
+	}
 
-	// TODO is it necessary to have timeout or handle disconnects, etc here?
-	return result, nil
+	c := sub.AfterTx(*hash, ledgerBeforeSubmit, *lastLedger)
+	select {
+	case result := <-c:
+		if result == nil || !result.Validated {
+			return result, fmt.Errorf("%s transaction %s %w %d", t.GetType(), *hash, ErrExpired, *lastLedger)
+		}
+		return result, nil
+	case <-ctx.Done():
+		sub.removeTxListener(c)
+		return nil, fmt.Errorf("%s transaction %s outcome unknown: %w", t.GetType(), *hash, ctx.Err())
+	}
 }
 
 // Helper to sign and submit a transaction.
@@ -146,6 +214,8 @@ func (sub *Subscription) AfterSequence(until uint32) <-chan uint32 {
 		c:     make(chan uint32, 1),
 		until: until,
 	}
+	sub.listenerMutex.Lock()
+	defer sub.listenerMutex.Unlock()
 	heap.Push(sub.sequenceListeners, listener)
 	return listener.c
 }
@@ -171,10 +241,16 @@ func (sub *Subscription) AfterTime(when interface{}) <-chan data.RippleTime {
 		c:     make(chan data.RippleTime, 1),
 		until: *until,
 	}
+	sub.listenerMutex.Lock()
+	defer sub.listenerMutex.Unlock()
 	heap.Push(sub.timeListeners, listener)
 	return listener.c
 }
 
+// AfterTx provides the result of a transaction, once it is validated
+// or ledger history from min through max (the transaction's
+// LastLedgerSequence) is available.  In the latter case, the result
+// is not validated, or nil if the transaction was not found.
 func (sub *Subscription) AfterTx(hash data.Hash256, min, max uint32) <-chan *websockets.TxResult {
 	listener := txWait{
 		hash: hash,
@@ -183,10 +259,24 @@ func (sub *Subscription) AfterTx(hash data.Hash256, min, max uint32) <-chan *web
 		max:  max,
 	}
 
+	sub.listenerMutex.Lock()
+	defer sub.listenerMutex.Unlock()
 	sub.txListeners.PushBack(&listener)
 	return listener.c
 }
 
+// removeTxListener stops waiting on the channel returned by AfterTx.
+func (sub *Subscription) removeTxListener(c <-chan *websockets.TxResult) {
+	sub.listenerMutex.Lock()
+	defer sub.listenerMutex.Unlock()
+	for l := sub.txListeners.Front(); l != nil; l = l.Next() {
+		if (<-chan *websockets.TxResult)(l.Value.(*txWait).c) == c {
+			sub.txListeners.Remove(l)
+			return
+		}
+	}
+}
+
 func (sub *Subscription) Ledgers() (uint32, uint32, error) {
 	// block until we get a ledger event from the server.
 	select {
@@ -258,6 +348,8 @@ func (sub *Subscription) messageLoop() {
 				//log.Printf("subscription: LedgerStreamMsg %d-%d\n", min, max) // debug
 				sub.mutex.Unlock() // TODO: should this be later in this function?
 
+				sub.listenerMutex.Lock()
+
 				// Inform anyone waiting on ledgers.
 				for len(*sub.sequenceListeners) > 0 && (*sub.sequenceListeners)[0].until <= max {
 					listener := heap.Pop(sub.sequenceListeners)
@@ -297,8 +389,15 @@ func (sub *Subscription) messageLoop() {
 										isLastTry := (min <= listener.min && max >= listener.max)
 
 										result, err := sub.Remote.Tx(listener.hash)
-										if err != nil {
-											glog.Errorln(err)
+										if IsTxnNotFound(err) && isLastTry {
+											// Not in complete history, so never will be.
+											listener.r = make(chan *websockets.TxResult, 1)
+											listener.r <- nil
+											close(listener.r)
+										} else if err != nil {
+											if !IsTxnNotFound(err) {
+												glog.Errorln(err)
+											}
 											listener.r = nil // We will try again, next ledger event.
 										} else {
 											// Let the listener know only about validated transactions.
@@ -316,6 +415,8 @@ func (sub *Subscription) messageLoop() {
 						}
 					}
 				}() // end closure
+
+				sub.listenerMutex.Unlock()
 			}
 
 			// Verbose
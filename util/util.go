@@ -78,21 +78,45 @@ func GetPrimaryKeypair() (Keypair, error) {
 	if primarySecret == "" {
 		return Keypair{}, errors.New("$RIPPLE_SECRET not found.")
 	}
-	return NewEcdsaFromSecret(primarySecret)
+	return NewKeypairFromSecret(primarySecret)
 }
 
 func NewEcdsaFromSecret(secret string) (Keypair, error) {
-	var addrseq uint32
+	return NewKeypair(secret, data.ECDSA)
+}
+
+// NewKeypairFromSecret detects key type from the secret, i.e. ed25519
+// when secret begins "sEd", otherwise secp256k1.
+func NewKeypairFromSecret(secret string) (Keypair, error) {
+	_, keyType, err := DecodeSeed(secret)
+	if err != nil {
+		return Keypair{}, err
+	}
+	return NewKeypair(secret, keyType)
+}
+
+// NewKeypair derives a keypair of the given type.  Use this, rather
+// than NewKeypairFromSecret, when an ed25519 secret may have been
+// encoded without the "sEd" prefix.
+func NewKeypair(secret string, keyType data.KeyType) (Keypair, error) {
 	pair := Keypair{
-		secret:   secret,
-		sequence: &addrseq,
-		KeyType:  data.ECDSA,
+		secret:  secret,
+		KeyType: keyType,
 	}
 
-	seed, err := data.NewSeedFromAddress(secret) // Note `Address` is probably a typo.
+	seed, encodedType, err := DecodeSeed(secret)
 	if err != nil {
 		return pair, err
 	}
+	if encodedType == data.Ed25519 && keyType != data.Ed25519 {
+		return pair, errors.Errorf("Expected %s secret, got %s", FormatKeyType(keyType), FormatKeyType(encodedType))
+	}
+
+	// ed25519 keys are not derived from a family generator, so have no sequence
+	if keyType != data.Ed25519 {
+		var addrseq uint32
+		pair.sequence = &addrseq
+	}
 
 	pair.Address = seed.AccountId(pair.KeyType, pair.sequence).String()
 
@@ -105,6 +129,22 @@ func (pair Keypair) Sign(tx data.Transaction) error {
 	return data.Sign(tx, pair.key, pair.sequence)
 }
 
+// SignMessage signs a message which is not a transaction, i.e. a
+// claim.  The message should begin with a prefix distinguishing it
+// from other kinds of message, unless it is a fixed-length hash.
+func (pair Keypair) SignMessage(msg []byte) (data.VariableLength, error) {
+	sig, err := crypto.Sign(pair.key.Private(pair.sequence), sha512Half(msg), msg)
+	if err != nil {
+		return nil, err
+	}
+	return data.VariableLength(sig), nil
+}
+
+// VerifyMessage checks a signature made by SignMessage.
+func VerifyMessage(pubkey data.PublicKey, msg []byte, signature []byte) (bool, error) {
+	return crypto.Verify(pubkey[:], sha512Half(msg), msg, signature)
+}
+
 // Helpers to submit a rubblelabs data.Transaction.
 
 // Function Sign adds signature to transaction and returns tx_blob suitable for submitting to network.
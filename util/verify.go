@@ -0,0 +1,78 @@
+package util
+
+import (
+	"bytes"
+
+	"github.com/pkg/errors"
+	"github.com/rubblelabs/ripple/crypto"
+	"github.com/rubblelabs/ripple/data"
+)
+
+// PublicKeyAccount returns the account whose master key is pubkey.
+// The same derivation applies to secp256k1 and ed25519 keys.
+func PublicKeyAccount(pubkey data.PublicKey) data.Account {
+	var account data.Account
+	copy(account[:], crypto.Sha256RipeMD160(pubkey[:]))
+	return account
+}
+
+// VerifySignature checks the TxnSignature of a single-signed
+// transaction against its SigningPubKey.  Note it does not check
+// whether the key may sign for the account.
+func VerifySignature(tx data.Transaction) error {
+	base := tx.GetBase()
+	if base.TxnSignature == nil || len(*base.TxnSignature) == 0 {
+		return errors.New("Transaction has no signature")
+	}
+	if base.SigningPubKey == nil || isEmptyPublicKey(base.SigningPubKey) {
+		return errors.New("Transaction has no SigningPubKey")
+	}
+	hash, msg, err := data.SigningHash(tx)
+	if err != nil {
+		return err
+	}
+	// ed25519 signs the prefixed message, as in data.Sign
+	ok, err := crypto.Verify(base.SigningPubKey[:], hash.Bytes(), append(tx.SigningPrefix().Bytes(), msg...), *base.TxnSignature)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("Bad transaction signature")
+	}
+	return nil
+}
+
+// VerifySigners checks each signature of a multi-signed transaction.
+// Note it does not check whether the signers are in the account's
+// signer list, or meet its quorum; that requires the ledger.
+func VerifySigners(tx data.Transaction, signers []Signer) error {
+	base := tx.GetBase()
+	if len(signers) == 0 {
+		return errors.New("Transaction has no Signers")
+	}
+	if base.TxnSignature != nil && len(*base.TxnSignature) > 0 {
+		return errors.New("Transaction has both a signature and Signers")
+	}
+
+	for i, s := range signers {
+		signer := s.Signer
+		if i > 0 && bytes.Compare(signers[i-1].Signer.Account[:], signer.Account[:]) >= 0 {
+			return errors.Errorf("Signers not sorted by account (%s follows %s)", signer.Account, signers[i-1].Signer.Account)
+		}
+		if len(signer.TxnSignature) == 0 || signer.SigningPubKey.IsZero() {
+			return errors.Errorf("Signer %s has no signature", signer.Account)
+		}
+		hash, msg, err := MultiSigningHash(tx, signer.Account)
+		if err != nil {
+			return err
+		}
+		ok, err := crypto.Verify(signer.SigningPubKey[:], hash.Bytes(), msg, signer.TxnSignature)
+		if err != nil {
+			return errors.Wrapf(err, "Signer %s", signer.Account)
+		}
+		if !ok {
+			return errors.Errorf("Bad signature from signer %s", signer.Account)
+		}
+	}
+	return nil
+}
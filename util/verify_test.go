@@ -0,0 +1,76 @@
+package util
+
+import (
+	"testing"
+
+	"github.com/dncohen/rcl/internal/txtest"
+	"github.com/rubblelabs/ripple/data"
+)
+
+func TestVerifySignature(t *testing.T) {
+	for _, keyType := range []data.KeyType{data.ECDSA, data.Ed25519} {
+		kp, err := NewKeypair("snoPBrXtMeMyMHUVTgbuqAfg1SUTb", keyType)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tx := txtest.Payment(t, kp.Address, "rvYAfWj5gh67oV6fW32ZzP3Aw4Eubs59B", "100/XRP")
+		err = kp.Sign(tx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = VerifySignature(tx)
+		if err != nil {
+			t.Errorf("%s: %s", FormatKeyType(keyType), err)
+		}
+		if PublicKeyAccount(*tx.SigningPubKey).String() != kp.Address {
+			t.Errorf("%s: wanted %s, got %s", FormatKeyType(keyType), kp.Address, PublicKeyAccount(*tx.SigningPubKey))
+		}
+
+		// altered after signing
+		tx.Sequence++
+		err = VerifySignature(tx)
+		if err == nil {
+			t.Errorf("%s: altered transaction passed verification", FormatKeyType(keyType))
+		}
+	}
+}
+
+func TestVerifySigners(t *testing.T) {
+	alice, err := NewKeypair("snoPBrXtMeMyMHUVTgbuqAfg1SUTb", data.ECDSA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := NewKeypair("snoPBrXtMeMyMHUVTgbuqAfg1SUTb", data.Ed25519)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tx := txtest.Payment(t, "rvYAfWj5gh67oV6fW32ZzP3Aw4Eubs59B", "rHb9CJAWyB4rj91VRWn96DkukG4bwdtyTh", "100/XRP")
+	var signers []Signer
+	for _, kp := range []Keypair{alice, bob} {
+		signer, err := kp.MultiSign(tx, txtest.Account(t, kp.Address))
+		if err != nil {
+			t.Fatal(err)
+		}
+		signers, err = AddSigners(tx, signers, signer)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = VerifySigners(tx, signers)
+	if err != nil {
+		t.Error(err)
+	}
+
+	// unsorted
+	err = VerifySigners(tx, []Signer{signers[1], signers[0]})
+	if err == nil {
+		t.Error("unsorted signers passed verification")
+	}
+
+	tx.Sequence++
+	err = VerifySigners(tx, signers)
+	if err == nil {
+		t.Error("altered transaction passed verification")
+	}
+}
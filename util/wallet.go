@@ -34,7 +34,7 @@ func NewWallet(options ...func(*Wallet) error) (*Wallet, error) {
 func SetSecret(secret string) func(*Wallet) error {
 	return func(w *Wallet) error {
 		var err error
-		w.signKey, err = NewEcdsaFromSecret(secret)
+		w.signKey, err = NewKeypairFromSecret(secret)
 		if err != nil {
 			return errors.Wrapf(err, "Failed to derive address from secret.")
 		}